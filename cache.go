@@ -0,0 +1,108 @@
+package formulate
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// fieldMetadata is the parsed struct tag information for a StructField.
+type fieldMetadata struct {
+	tags       map[string]string
+	validators []ValidatorKey
+}
+
+// structFieldCache is a map of reflect.Type to []StructField.
+var structFieldCache sync.Map
+
+// cachedStructFields returns the StructFields of the struct type t. The fields and their parsed struct tags are
+// cached per type, so that repeated calls to Encode and Decode on the same type do not re-parse struct tags.
+// The returned slice is shared and must not be modified, but the StructFields within it may be copied.
+func cachedStructFields(t reflect.Type) []StructField {
+	if fields, ok := structFieldCache.Load(t); ok {
+		return fields.([]StructField)
+	}
+
+	fields := make([]StructField, t.NumField())
+
+	for i := range fields {
+		structField := t.Field(i)
+		tags := parseTags(structField.Tag)
+
+		fields[i] = StructField{
+			StructField: structField,
+			metadata: &fieldMetadata{
+				tags:       tags,
+				validators: parseValidators(tags["validators"]),
+			},
+		}
+	}
+
+	structFieldCache.Store(t, fields)
+
+	return fields
+}
+
+// parseTags parses all of the key:"value" pairs in tag. It follows the same conventions as reflect.StructTag.Lookup.
+func parseTags(tag reflect.StructTag) map[string]string {
+	tags := make(map[string]string)
+
+	for tag != "" {
+		// skip leading space.
+		i := 0
+
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+
+		tag = tag[i:]
+
+		if tag == "" {
+			break
+		}
+
+		// scan to colon. a space, a quote or a control character is a syntax error.
+		i = 0
+
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			break
+		}
+
+		name := string(tag[:i])
+		tag = tag[i+1:]
+
+		// scan quoted string to find value.
+		i = 1
+
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+
+		if i >= len(tag) {
+			break
+		}
+
+		quotedValue := string(tag[:i+1])
+		tag = tag[i+1:]
+
+		value, err := strconv.Unquote(quotedValue)
+
+		if err != nil {
+			break
+		}
+
+		if _, ok := tags[name]; !ok {
+			// reflect.StructTag.Lookup returns the first matching key.
+			tags[name] = value
+		}
+	}
+
+	return tags
+}
@@ -0,0 +1,20 @@
+package formulate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCachedStructFields(t *testing.T) {
+	typ := reflect.TypeOf(YourDetails{})
+
+	fields := cachedStructFields(typ)
+
+	for i, field := range fields {
+		for _, key := range []string{"name", "step", "min", "validators", "pattern", "show", "type", "elem"} {
+			assertEquals(t, field.tag(key), typ.Field(i).Tag.Get(key))
+		}
+	}
+
+	assertEquals(t, &cachedStructFields(typ)[0], &fields[0])
+}
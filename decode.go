@@ -1,23 +1,51 @@
 package formulate
 
 import (
+	"context"
+	"encoding"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
 	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
-// HTTPDecoder takes a set of url values and decodes them.
+// HTTPDecoder takes a set of url values and decodes them. Fields which are hidden by its ShowConditions are not
+// decoded, so the HTTPDecoder should be given the same ShowConditions as the HTMLEncoder which rendered the form.
 type HTTPDecoder struct {
 	ShowConditions
 
 	form url.Values
 
+	// submittedForm is a copy of form before any values are decoded, used to evaluate requiredwhen conditions.
+	submittedForm url.Values
+
 	validators                map[ValidatorKey]Validator
 	validationStore           ValidationStore
 	setValueOnValidationError bool
-	numValidationErrors       int
+	urlDecodeValues           bool
+	timeLocation              *time.Location
+	validationErrors          ValidationErrors
+	parsers                   map[reflect.Type]ValueParser
+
+	customDecoderErrorsAsValidation bool
+	invalidInputAsValidationError   bool
+	honeypot                        string
+	bracketNotation                 bool
+	jsonUnmarshaler                 JSONUnmarshaler
+	jsonSchema                      *jsonSchema
+	caseInsensitiveOptions          bool
+	elementNamePrefix               string
+	fullElementNames                bool
+
+	stripZeroWidthCharacters bool
+	unicodeNormalizer        UnicodeNormalizer
+	trimSpace                bool
 }
 
 // NewDecoder creates a new HTTPDecoder.
@@ -27,6 +55,7 @@ func NewDecoder(form url.Values) *HTTPDecoder {
 		form:           form,
 
 		validators:                make(map[ValidatorKey]Validator),
+		parsers:                   make(map[reflect.Type]ValueParser),
 		validationStore:           NewMemoryValidationStore(),
 		setValueOnValidationError: false,
 	}
@@ -45,7 +74,226 @@ func (h *HTTPDecoder) SetValueOnValidationError(b bool) {
 	h.setValueOnValidationError = b
 }
 
+// SetURLDecodeValues indicates whether form values should be URL decoded (using url.QueryUnescape) before they are
+// decoded. This can be used when the form values come from a source which has not already decoded them. Values which
+// cannot be URL decoded are not decoded into the struct, and a validation error is added for them.
+func (h *HTTPDecoder) SetURLDecodeValues(b bool) {
+	h.urlDecodeValues = b
+}
+
+// SetCustomDecoderErrorsAsValidation indicates whether errors returned by a CustomDecoder should be recorded as
+// a validation error on its field, using the error's message, rather than aborting the Decode with a DecodeError.
+// When enabled, decoding continues with the remaining fields, and Decode returns a ValidationErrors once all fields
+// have been decoded. Context errors are always returned. CustomDecoder errors are only affected by this option, not
+// by SetInvalidInputAsValidationError.
+func (h *HTTPDecoder) SetCustomDecoderErrorsAsValidation(b bool) {
+	h.customDecoderErrorsAsValidation = b
+}
+
+// InvalidInputValidationMessage is the validation message used for values which can't be parsed into their field
+// when HTTPDecoder.SetInvalidInputAsValidationError is enabled.
+var InvalidInputValidationMessage = "This value is not valid"
+
+// InvalidJSONValidationMessage is the validation message used for JSON values of the wrong shape for their field
+// (e.g. an object submitted for a slice field) when HTTPDecoder.SetInvalidInputAsValidationError is enabled. It is
+// passed the expected and submitted JSON types, e.g. "array" and "object".
+var InvalidJSONValidationMessage = "This value must be a JSON %s, not a JSON %s"
+
+// SetInvalidInputAsValidationError indicates whether form values which can't be parsed into their field (e.g. a
+// value which is not a number, for a number field, or JSON of the wrong shape for a slice or map field) should be recorded as a validation error on the field, rather
+// than aborting the Decode with a DecodeError. When enabled, the field is left unchanged, decoding continues with
+// the remaining fields, and Decode returns a ValidationErrors once all fields have been decoded. Errors returned by
+// a CustomDecoder are not affected, see SetCustomDecoderErrorsAsValidation.
+func (h *HTTPDecoder) SetInvalidInputAsValidationError(b bool) {
+	h.invalidInputAsValidationError = b
+}
+
+// SetHoneypot sets the name of the honeypot input rendered by HTMLEncoder.SetHoneypot. The honeypot value is
+// removed from the form before decoding, so it is never decoded into the struct. If it is not empty, Decode
+// returns ErrHoneypotFilled without decoding any fields.
+func (h *HTTPDecoder) SetHoneypot(name string) {
+	h.honeypot = name
+}
+
+// JSONUnmarshaler decodes the JSON value of a slice, array or map field into v. See HTTPDecoder.SetJSONUnmarshaler.
+type JSONUnmarshaler func(data []byte, v interface{}) error
+
+// SetJSONUnmarshaler sets the function used to decode the values of slice, array and map fields which are submitted
+// as JSON. By default, json.Unmarshal is used. See also HTMLEncoder.SetJSONMarshaler.
+func (h *HTTPDecoder) SetJSONUnmarshaler(unmarshaler JSONUnmarshaler) {
+	h.jsonUnmarshaler = unmarshaler
+}
+
+// unmarshalJSON decodes data into v using the HTTPDecoder's JSONUnmarshaler, if one is set.
+func (h *HTTPDecoder) unmarshalJSON(data []byte, v interface{}) error {
+	if h.jsonUnmarshaler != nil {
+		return h.jsonUnmarshaler(data, v)
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// UnicodeNormalizer normalizes a string to a Unicode normalization form. The forms of
+// golang.org/x/text/unicode/norm (e.g. norm.NFC) implement UnicodeNormalizer. See HTTPDecoder.SetUnicodeNormalization.
+type UnicodeNormalizer interface {
+	String(s string) string
+}
+
+// SetUnicodeNormalization sets the Unicode normalization form which the values of string fields are normalized to
+// when they are decoded, e.g. SetUnicodeNormalization(norm.NFC), so that visually identical values compare equal.
+//
+// The values of string fields are cleaned in the following order: zero width characters are removed (see
+// SetStripZeroWidthCharacters), the value is normalized, then leading and trailing whitespace is trimmed (see
+// SetTrimSpace). Password fields are never cleaned.
+func (h *HTTPDecoder) SetUnicodeNormalization(normalizer UnicodeNormalizer) {
+	h.unicodeNormalizer = normalizer
+}
+
+// SetStripZeroWidthCharacters tells the HTTPDecoder to remove zero width characters (e.g. U+200B ZERO WIDTH SPACE
+// and U+FEFF ZERO WIDTH NO-BREAK SPACE) from the values of string fields. Note that this includes the zero width
+// joiner, which is used in some emoji sequences. See SetUnicodeNormalization for the order in which values are cleaned.
+func (h *HTTPDecoder) SetStripZeroWidthCharacters(b bool) {
+	h.stripZeroWidthCharacters = b
+}
+
+// SetTrimSpace tells the HTTPDecoder to trim leading and trailing whitespace from the values of string fields.
+// See SetUnicodeNormalization for the order in which values are cleaned.
+func (h *HTTPDecoder) SetTrimSpace(b bool) {
+	h.trimSpace = b
+}
+
+// cleanString removes zero width characters from, normalizes and trims s, depending on the options of the HTTPDecoder.
+func (h *HTTPDecoder) cleanString(s string) string {
+	if h.stripZeroWidthCharacters {
+		s = strings.Map(func(r rune) rune {
+			if isZeroWidth(r) {
+				return -1
+			}
+
+			return r
+		}, s)
+	}
+
+	if h.unicodeNormalizer != nil {
+		s = h.unicodeNormalizer.String(s)
+	}
+
+	if h.trimSpace {
+		s = strings.TrimSpace(s)
+	}
+
+	return s
+}
+
+// passwordType is the type of Password fields, which are not cleaned by cleanString.
+var passwordType = reflect.TypeOf(Password(""))
+
+// isZeroWidth determines if r is an invisible zero width character.
+func isZeroWidth(r rune) bool {
+	switch r {
+	case '\u200B', '\u200C', '\u200D', '\u2060', '\uFEFF':
+		return true
+	default:
+		return false
+	}
+}
+
+// SetJSONSchema sets a JSON Schema (https://json-schema.org) which the decoded struct is validated against. After the
+// form is decoded, the struct is validated against the schema, and each violation is added as a validation error on
+// the field at its path, e.g. Address.HouseNumber. The struct is decoded before it is validated, so fields which
+// violate the schema are still set.
+//
+// The schema is compiled once, when it is set. The validation keywords which are generated by JSONSchema are
+// supported: type, properties, required, items, enum, minimum, maximum, multipleOf, minLength, maxLength and
+// pattern, as are the annotations $schema, title, description, readOnly and format (which, as an annotation, is not
+// validated). An error wrapping ErrInvalidJSONSchema is returned if the schema can't be parsed, uses any other
+// keyword, or has a pattern which is not a valid regular expression, and the previous schema is kept.
+//
+// The struct is validated in the same form as the schemas generated by JSONSchema: property names are the names of
+// the struct fields (json tags are ignored), and values are formatted as they are rendered by the HTMLEncoder, e.g.
+// time.Time values use the layout of the field's format tag and big.Rat values are fractions. Nil pointers, other
+// than those of nullable selects, and CustomEncoders are treated as absent. Validation messages can be changed with
+// SchemaValidationMessages.
+func (h *HTTPDecoder) SetJSONSchema(schema []byte) error {
+	compiled, err := compileSchema(schema)
+
+	if err != nil {
+		return err
+	}
+
+	h.jsonSchema = compiled
+
+	return nil
+}
+
+// validateJSONSchema validates the decoded struct val against the HTTPDecoder's JSON Schema.
+func (h *HTTPDecoder) validateJSONSchema(val reflect.Value) error {
+	value, _ := schemaValue(val, StructField{timeLocation: h.timeLocation})
+
+	for _, violation := range validateSchema(h.jsonSchema, value, "") {
+		if err := h.addValidationError(violation.path, ValidationError{
+			Value: violation.value,
+			Error: violation.message(),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetBracketNotation tells the HTTPDecoder to accept the names of nested struct fields in bracket notation,
+// e.g. Address[HouseName] rather than Address.HouseName, as rendered by HTMLEncoder.SetBracketNotation. The bracket
+// names are resolved using the type being decoded, so entries of maps and slices within nested structs (e.g.
+// Address[Tags][0]) are still decoded as entries. Dotted names are also accepted.
+func (h *HTTPDecoder) SetBracketNotation(b bool) {
+	h.bracketNotation = b
+}
+
+// SetElementNamePrefix sets the prefix of the names of the form elements, as rendered by
+// HTMLEncoder.SetElementNamePrefix. Form values whose names do not begin with the prefix, e.g. those of another form
+// on the same page, are ignored.
+func (h *HTTPDecoder) SetElementNamePrefix(prefix string) {
+	h.elementNamePrefix = prefix
+}
+
+// SetFullElementNames tells the HTTPDecoder that the names of the form elements begin with the root key of the
+// decoded struct type, as rendered by HTMLEncoder.SetFullElementNames. Form values whose names do not begin with the
+// root key are ignored.
+func (h *HTTPDecoder) SetFullElementNames(b bool) {
+	h.fullElementNames = b
+}
+
+// SetCaseInsensitiveOptions tells the HTTPDecoder to match the submitted values of Select, Enum, EnumOptions,
+// RadioList and CheckboxGroup fields to their options case-insensitively. A value which matches an option is decoded
+// as the option's value exactly as declared, e.g. a submitted "gbr" is decoded as "GBR", so that stored values
+// are consistent. Values which don't match any option are decoded as submitted.
+func (h *HTTPDecoder) SetCaseInsensitiveOptions(b bool) {
+	h.caseInsensitiveOptions = b
+}
+
+// SetTimeLocation sets the location which time.Time values are parsed in. The form values of time inputs
+// do not contain a timezone, so they are parsed as UTC by default. The tz struct tag can be used to override
+// the location of a single field.
+func (h *HTTPDecoder) SetTimeLocation(loc *time.Location) {
+	h.timeLocation = loc
+}
+
+// ValueParser parses the form values of a field into a value of the field's type. See HTTPDecoder.RegisterParser.
+type ValueParser func(values []string) (reflect.Value, error)
+
+// RegisterParser registers a ValueParser for fields of type t. When decoding a field of type t, all of its form
+// values are passed to the parser, and the value it returns is set on the field (after validation), instead of the
+// value being parsed based on its kind. This allows types which don't implement CustomDecoder, such as types from
+// other packages, to be decoded. Errors returned by the parser are returned by Decode. If the parser returns an
+// invalid reflect.Value, the field is left unchanged.
+func (h *HTTPDecoder) RegisterParser(t reflect.Type, parser ValueParser) {
+	h.parsers[t] = parser
+}
+
 // AddValidators registers Validators to the decoder.
+// Validators are only run for visible fields. If a field is hidden by any show condition (including
+// global show conditions), it is not decoded and its validators are skipped.
 func (h *HTTPDecoder) AddValidators(validators ...Validator) {
 	for _, validator := range validators {
 		h.validators[ValidatorKey(validator.TagName())] = validator
@@ -69,8 +317,16 @@ func (h *HTTPDecoder) getValidators(keys []ValidatorKey) []Validator {
 }
 
 // Decode the given values into a provided interface{}. Note that the underlying
-// value must be a pointer.
+// value must be a pointer. If any fields fail validation, a ValidationErrors is returned,
+// which matches ErrFormFailedValidation when using errors.Is. Fields of kinds which can't
+// be represented in a form (funcs, channels and complex numbers) are skipped and left unchanged.
 func (h *HTTPDecoder) Decode(data interface{}) error {
+	return h.DecodeContext(context.Background(), data)
+}
+
+// DecodeContext is the same as Decode, but stops decoding and returns ctx.Err() if ctx is cancelled
+// before decoding is complete. ctx is passed to any ContextValidators.
+func (h *HTTPDecoder) DecodeContext(ctx context.Context, data interface{}) error {
 	val := reflect.ValueOf(data)
 
 	if val.Kind() != reflect.Ptr {
@@ -79,11 +335,53 @@ func (h *HTTPDecoder) Decode(data interface{}) error {
 
 	elem := val.Elem()
 
-	// look for FormAwareValidators and set the form before we decode the data.
+	h.validationErrors = make(ValidationErrors)
+
+	if h.urlDecodeValues {
+		if err := h.unescapeForm(); err != nil {
+			return err
+		}
+	}
+
+	if h.honeypot != "" {
+		honeypot := h.form[h.honeypot]
+		delete(h.form, h.honeypot)
+
+		for _, value := range honeypot {
+			if value != "" {
+				return ErrHoneypotFilled
+			}
+		}
+	}
+
+	if h.elementNamePrefix != "" {
+		h.form = stripElementNamePrefix(h.form, h.elementNamePrefix)
+	}
+
+	if h.fullElementNames {
+		h.form = stripElementNamePrefix(h.form, rootKey(elem.Type())+fieldSeparator)
+	}
+
+	if h.bracketNotation {
+		h.form = convertBracketNotation(h.form, elem.Type())
+	}
+
+	h.submittedForm = make(url.Values, len(h.form))
+
+	for key, values := range h.form {
+		h.submittedForm[key] = append([]string(nil), values...)
+	}
+
+	// look for FormAwareValidators and StructAwareValidators and set the form
+	// and struct before we decode the data.
 	for _, validator := range h.validators {
 		if formAwareValidator, ok := validator.(FormAwareValidator); ok {
 			formAwareValidator.SetForm(h.form)
 		}
+
+		if structAwareValidator, ok := validator.(StructAwareValidator); ok {
+			structAwareValidator.SetStruct(elem)
+		}
 	}
 
 	if decoder, ok := data.(CustomDecoder); ok {
@@ -106,16 +404,22 @@ func (h *HTTPDecoder) Decode(data interface{}) error {
 		panic("formulate: decode target underlying value must be struct")
 	}
 
-	if err := h.decode(elem, elem.Type().String(), nil); err != nil {
+	if err := h.decode(ctx, elem, rootKey(elem.Type()), StructField{}); err != nil {
 		return err
 	}
 
-	if h.numValidationErrors > 0 {
+	if h.jsonSchema != nil {
+		if err := h.validateJSONSchema(elem); err != nil {
+			return err
+		}
+	}
+
+	if len(h.validationErrors) > 0 {
 		if err := h.validationStore.SetFormValue(data); err != nil {
 			return err
 		}
 
-		return ErrFormFailedValidation
+		return h.validationErrors
 	}
 
 	return nil
@@ -133,14 +437,98 @@ func (h *HTTPDecoder) getFormValues(key string) []string {
 	return vals
 }
 
-func (h *HTTPDecoder) decode(val reflect.Value, key string, validators []Validator) error {
+// DecodeError is returned by HTTPDecoder.Decode when a form value can't be parsed into the type of its field,
+// e.g. when the value of a number field is not a number, or when a CustomDecoder returns an error. Use errors.As to
+// retrieve it. The underlying error (e.g. a *strconv.NumError) is available with errors.Unwrap.
+type DecodeError struct {
+	// Field is the form element name of the field, e.g. "Address.HouseNumber".
+	Field string
+	// Value is the form value which could not be parsed.
+	Value string
+	// Err is the error returned when parsing the value.
+	Err error
+
+	// customDecoder is set if Err was returned by a CustomDecoder, in which case the error is only recorded as a
+	// validation error if HTTPDecoder.SetCustomDecoderErrorsAsValidation is enabled.
+	customDecoder bool
+}
+
+func newDecodeError(key, value string, err error) *DecodeError {
+	return &DecodeError{
+		Field: FormElementName(key),
+		Value: value,
+		Err:   err,
+	}
+}
+
+// Error implements the error interface.
+func (d *DecodeError) Error() string {
+	return fmt.Sprintf("formulate: invalid value %q for %s: %v", d.Value, d.Field, d.Err)
+}
+
+// Unwrap returns the error returned when parsing the value.
+func (d *DecodeError) Unwrap() error {
+	return d.Err
+}
+
+func (h *HTTPDecoder) decode(ctx context.Context, val reflect.Value, key string, field StructField) error {
+	if parser, ok := h.parsers[val.Type()]; ok {
+		return h.decodeParsedValue(ctx, val, key, field, parser)
+	}
+
+	if h.caseInsensitiveOptions {
+		h.canonicalizeOptionValues(val, key)
+	}
+
+	if isNullableSelect(val.Type()) {
+		if formValues := h.getFormValues(key); len(formValues) > 0 && formValues[0] == "" {
+			// the empty option of a nullable select was chosen.
+			PopFormValue(h.form, FormElementName(key))
+
+			null := reflect.Zero(val.Type())
+
+			if ok, err := h.passedValidation(ctx, key, null.Interface(), field); ok && err == nil {
+				val.Set(null)
+			} else if err != nil {
+				return err
+			}
+
+			return nil
+		}
+	}
+
+	if val.Kind() == reflect.Ptr && val.Type().Elem().Implements(customDecoderType) && val.CanSet() {
+		// the pointer also implements CustomDecoder, but the decoded value is of its element type.
+		return h.decodeScalarPtr(ctx, val, key, field)
+	}
+
 	if val.CanInterface() {
 		switch a := val.Interface().(type) {
 		case CustomDecoder:
-			decodedFormVal, err := a.DecodeFormValue(h.form, key, h.getFormValues(key))
+			formValues := h.getFormValues(key)
+			decodedFormVal, err := a.DecodeFormValue(h.form, key, formValues)
 
 			if err != nil {
-				return err
+				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+					return err
+				}
+
+				if !h.customDecoderErrorsAsValidation {
+					decodeErr := newDecodeError(key, strings.Join(formValues, ","), err)
+					decodeErr.customDecoder = true
+
+					return decodeErr
+				}
+
+				var value interface{}
+
+				if len(formValues) == 1 {
+					value = formValues[0]
+				} else if len(formValues) > 1 {
+					value = formValues
+				}
+
+				return h.addValidationError(FormElementName(key), ValidationError{Value: value, Error: err.Error()})
 			}
 
 			if !decodedFormVal.IsValid() {
@@ -150,7 +538,7 @@ func (h *HTTPDecoder) decode(val reflect.Value, key string, validators []Validat
 			if decodedFormVal.CanInterface() {
 				i := decodedFormVal.Interface()
 
-				if ok, err := h.passedValidation(key, i, validators); ok && err == nil {
+				if ok, err := h.passedValidation(ctx, key, i, field); ok && err == nil {
 					val.Set(decodedFormVal)
 				} else if err != nil {
 					return err
@@ -161,38 +549,88 @@ func (h *HTTPDecoder) decode(val reflect.Value, key string, validators []Validat
 			}
 
 			return nil
+		case OptionalValue:
+			return h.decodeOptional(ctx, val, key, field)
+		case Flags:
+			return h.decodeFlags(ctx, val, key, field, a.FlagOptions())
+		case Select:
+			if val.Kind() == reflect.Slice && a.SelectMultiple() {
+				return h.decodeMultipleValues(ctx, val, key, field)
+			}
+		case CheckboxGroup:
+			if val.Kind() == reflect.Slice {
+				return h.decodeMultipleValues(ctx, val, key, field)
+			}
 		case time.Time:
 			formValue, ok := PopFormValue(h.form, FormElementName(key))
 
 			var t time.Time
 
-			if ok && formValue != "" {
-				var err error
+			loc, err := field.location()
+
+			if err != nil {
+				return err
+			}
 
-				t, err = time.Parse(timeFormat, formValue)
+			if loc == nil {
+				loc = time.UTC
+			}
+
+			if ok && formValue != "" {
+				t, err = parseTimeValue(field.TimeFormat(), formValue, loc)
 
 				if err != nil {
-					return err
+					return newDecodeError(key, formValue, err)
 				}
+			} else if field.defaultsToNow() {
+				t = time.Now().In(loc)
 			}
 
-			if ok, err := h.passedValidation(key, t, validators); ok && err == nil {
+			if ok, err := h.passedValidation(ctx, key, t, field); ok && err == nil {
 				val.Set(reflect.ValueOf(t))
 			} else if err != nil {
 				return err
 			}
 
 			return nil
+		case big.Rat:
+			formValue, _ := PopFormValue(h.form, FormElementName(key))
+
+			r := new(big.Rat)
+
+			if formValue != "" {
+				// SetString accepts both fractions (1/3) and decimals (0.25).
+				if _, ok := r.SetString(formValue); !ok {
+					return newDecodeError(key, formValue, fmt.Errorf("%w: %q", ErrInvalidRat, formValue))
+				}
+			}
+
+			if ok, err := h.passedValidation(ctx, key, r, field); ok && err == nil {
+				val.Set(reflect.ValueOf(r).Elem())
+			} else if err != nil {
+				return err
+			}
+
+			return nil
+		}
+
+		if isTextType(val.Type()) {
+			return h.decodeText(ctx, val, key, field)
 		}
+
 	}
 
 	switch val.Kind() {
 	case reflect.Struct:
 		// recurse over the fields
-		for i := 0; i < val.NumField(); i++ {
-			field := val.Field(i)
-			fieldType := val.Type().Field(i)
-			structField := StructField{StructField: fieldType}
+		for i, structField := range cachedStructFields(val.Type()) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			fieldValue := val.Field(i)
+			structField.timeLocation = h.timeLocation
+			structField.parent = val
 
 			if !structField.IsExported() {
 				continue
@@ -203,7 +641,47 @@ func (h *HTTPDecoder) decode(val reflect.Value, key string, validators []Validat
 				continue
 			}
 
-			err := h.decode(field, key+fieldSeparator+fieldType.Name, h.getValidators(structField.Validators()))
+			if structField.Computed() != "" {
+				// computed fields are display-only.
+				continue
+			}
+
+			if structField.Disabled() {
+				// disabled elements are not submitted, so disabled fields and structs are left unchanged.
+				continue
+			}
+
+			if structField.ReadOnly() {
+				// read only fields can't be changed, so their submitted values are not trusted and the fields are
+				// left unchanged. A modified value is reported as a validation error.
+				if err := h.verifyReadOnly(FormElementName(key + fieldSeparator + structField.Name)); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			fieldKey := key + fieldSeparator + structField.Name
+
+			if !h.submitted(FormElementName(fieldKey)) {
+				// absent fields are not decoded, but omitting a field must not bypass its requiredwhen condition.
+				if err := h.validateAbsentRequiredWhen(FormElementName(fieldKey), structField); err != nil {
+					return err
+				}
+
+				structField.absent = true
+			}
+
+			err := h.decode(ctx, fieldValue, fieldKey, structField)
+
+			var decodeErr *DecodeError
+
+			if err != nil && h.invalidInputAsValidationError && errors.As(err, &decodeErr) && !decodeErr.customDecoder {
+				err = h.addValidationError(decodeErr.Field, ValidationError{
+					Value: decodeErr.Value,
+					Error: invalidInputMessage(decodeErr),
+				})
+			}
 
 			if err != nil {
 				return err
@@ -211,17 +689,28 @@ func (h *HTTPDecoder) decode(val reflect.Value, key string, validators []Validat
 		}
 		return nil
 	case reflect.Ptr:
+		if isCollectionKind(val.Type().Elem().Kind()) && !isTextType(val.Type().Elem()) && val.CanSet() {
+			return h.decodeCollectionPtr(ctx, val, key, field)
+		}
+
+		if isSupportedKind(val.Type().Elem().Kind()) && val.CanSet() {
+			return h.decodeScalarPtr(ctx, val, key, field)
+		}
+
 		// dereference ptr, decode again
 		if val.IsNil() && val.CanAddr() {
 			val.Set(reflect.New(val.Type().Elem()))
 		}
 
-		return h.decode(val.Elem(), key, validators)
+		return h.decode(ctx, val.Elem(), key, field)
+	case reflect.Func, reflect.Chan, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		// these kinds can't be represented in a form, so they are skipped without consuming any form values.
+		return nil
 	case reflect.Interface:
 		n := reflect.New(val.Elem().Type())
 		n.Elem().Set(val.Elem())
 
-		if err := h.decode(n, key, validators); err != nil {
+		if err := h.decode(ctx, n, key, field); err != nil {
 			return err
 		}
 
@@ -230,6 +719,18 @@ func (h *HTTPDecoder) decode(val reflect.Value, key string, validators []Validat
 		return nil
 	}
 
+	if val.Kind() == reflect.Map {
+		if mapKeys := mapFormKeys(h.form, val, key); len(mapKeys) > 0 {
+			return h.decodeMap(ctx, val, key, mapKeys, field)
+		}
+	}
+
+	if val.Kind() == reflect.Slice {
+		if indexes := sliceFormIndexes(h.form, key); len(indexes) > 0 {
+			return h.decodeSlice(ctx, val, key, indexes, field)
+		}
+	}
+
 	formValue, ok := PopFormValue(h.form, FormElementName(key))
 
 	if !ok {
@@ -241,7 +742,11 @@ func (h *HTTPDecoder) decode(val reflect.Value, key string, validators []Validat
 
 	switch val.Kind() {
 	case reflect.String:
-		if ok, err := h.passedValidation(key, formValue, validators); ok && err == nil {
+		if val.Type() != passwordType {
+			formValue = h.cleanString(formValue)
+		}
+
+		if ok, err := h.passedValidation(ctx, key, formValue, field); ok && err == nil {
 			val.SetString(formValue)
 		} else if err != nil {
 			return err
@@ -256,11 +761,11 @@ func (h *HTTPDecoder) decode(val reflect.Value, key string, validators []Validat
 			f, err = strconv.ParseFloat(formValue, 64)
 
 			if err != nil {
-				return err
+				return newDecodeError(key, formValue, err)
 			}
 		}
 
-		if ok, err := h.passedValidation(key, f, validators); ok && err == nil {
+		if ok, err := h.passedValidation(ctx, key, f, field); ok && err == nil {
 			val.SetFloat(f)
 		} else if err != nil {
 			return err
@@ -275,11 +780,11 @@ func (h *HTTPDecoder) decode(val reflect.Value, key string, validators []Validat
 			i, err = strconv.ParseInt(formValue, 10, 0)
 
 			if err != nil {
-				return err
+				return newDecodeError(key, formValue, err)
 			}
 		}
 
-		if ok, err := h.passedValidation(key, i, validators); ok && err == nil {
+		if ok, err := h.passedValidation(ctx, key, i, field); ok && err == nil {
 			val.SetInt(i)
 		} else if err != nil {
 			return err
@@ -294,11 +799,11 @@ func (h *HTTPDecoder) decode(val reflect.Value, key string, validators []Validat
 			i, err = strconv.ParseUint(formValue, 10, 0)
 
 			if err != nil {
-				return err
+				return newDecodeError(key, formValue, err)
 			}
 		}
 
-		if ok, err := h.passedValidation(key, i, validators); ok && err == nil {
+		if ok, err := h.passedValidation(ctx, key, i, field); ok && err == nil {
 			val.SetUint(i)
 		} else if err != nil {
 			return err
@@ -306,23 +811,25 @@ func (h *HTTPDecoder) decode(val reflect.Value, key string, validators []Validat
 
 		return nil
 	case reflect.Bool:
-		if formValue == "on" {
+		// a checkbox may be submitted alongside a hidden fallback value, so the field is true if any of its values are.
+		formValues := append([]string{formValue}, popAllFormValues(h.form, FormElementName(key))...)
+
+		if containsString(formValues, "on") {
 			val.SetBool(true)
 		} else {
-			var i int64
-			var err error
+			b := false
 
-			if formValue != "" {
-				i, err = strconv.ParseInt(formValue, 10, 0)
+			for _, formValue := range formValues {
+				isTrue, err := parseBool(formValue)
 
 				if err != nil {
-					return err
+					return newDecodeError(key, formValue, err)
 				}
-			}
 
-			b := i == 1
+				b = b || isTrue
+			}
 
-			if ok, err := h.passedValidation(key, b, validators); ok && err == nil {
+			if ok, err := h.passedValidation(ctx, key, b, field); ok && err == nil {
 				val.SetBool(b)
 			} else if err != nil {
 				return err
@@ -338,8 +845,8 @@ func (h *HTTPDecoder) decode(val reflect.Value, key string, validators []Validat
 			return nil
 		}
 
-		if err := json.Unmarshal([]byte(formValue), i.Interface()); err != nil {
-			return err
+		if err := h.unmarshalJSON([]byte(formValue), i.Interface()); err != nil {
+			return newDecodeError(key, formValue, err)
 		}
 
 		val.Set(i.Elem())
@@ -349,29 +856,799 @@ func (h *HTTPDecoder) decode(val reflect.Value, key string, validators []Validat
 	}
 }
 
-func (h *HTTPDecoder) passedValidation(key string, value interface{}, validators []Validator) (bool, error) {
-	ok := true
+// canonicalizeOptionValues replaces the form values of key which case-insensitively match one of the options of val
+// with the option's value, e.g. "gbr" with "GBR". Values which exactly match an option are left as they are.
+func (h *HTTPDecoder) canonicalizeOptionValues(val reflect.Value, key string) {
+	name := FormElementName(key)
+	formValues, ok := h.form[name]
 
-	for _, validator := range validators {
-		valid, message := validator.Validate(value)
+	if !ok {
+		return
+	}
 
-		if !valid {
-			h.numValidationErrors++
+	options := optionsOf(val)
 
-			err := h.validationStore.AddValidationError(FormElementName(key), ValidationError{
-				Value: value,
-				Error: message,
-			})
+	if len(options) == 0 {
+		return
+	}
 
-			if err != nil {
-				return ok, err
+	optionValues := make([]string, len(options))
+
+	for i, opt := range options {
+		optionValues[i] = toString(opt.Value)
+	}
+
+	canonical := make([]string, len(formValues))
+
+	for i, formValue := range formValues {
+		canonical[i] = formValue
+
+		if containsString(optionValues, formValue) {
+			continue
+		}
+
+		for _, optionValue := range optionValues {
+			if strings.EqualFold(formValue, optionValue) {
+				canonical[i] = optionValue
+				break
 			}
+		}
+	}
 
-			ok = false
+	h.form[name] = canonical
+}
+
+// optionsOf returns the options of v if it is a Select (including a nullable Select), Enum, EnumOptions, RadioList
+// or CheckboxGroup.
+func optionsOf(v reflect.Value) []Option {
+	if isNullableSelect(v.Type()) {
+		if v.IsNil() {
+			v = reflect.Zero(v.Type().Elem())
+		} else {
+			v = v.Elem()
 		}
 	}
 
-	return ok || h.setValueOnValidationError, nil
+	if !v.CanInterface() {
+		return nil
+	}
+
+	switch a := v.Interface().(type) {
+	case Select:
+		return a.SelectOptions()
+	case Enum, EnumOptions:
+		return newEnumSelect(a).SelectOptions()
+	case RadioList:
+		return a.RadioOptions()
+	case CheckboxGroup:
+		return a.CheckboxOptions()
+	default:
+		return nil
+	}
+}
+
+// invalidInputMessage returns the validation message for a value which could not be parsed into its field.
+func invalidInputMessage(err *DecodeError) string {
+	var typeErr *json.UnmarshalTypeError
+
+	if errors.As(err.Err, &typeErr) && typeErr.Type != nil {
+		// Value is e.g. "object", or "number 300" for a number which overflows its type.
+		submitted := strings.SplitN(typeErr.Value, " ", 2)[0]
+
+		return fmt.Sprintf(InvalidJSONValidationMessage, jsonTypeName(typeErr.Type), submitted)
+	}
+
+	return InvalidInputValidationMessage
+}
+
+// jsonTypeName returns the name of the JSON type which is decoded into values of type t.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Ptr:
+		return jsonTypeName(t.Elem())
+	default:
+		return "number"
+	}
+}
+
+// parseBool parses a boolean form value, ignoring case. "true", "on", "yes" and "1" are true, and "false", "off",
+// "no", "0" and "" are false. Other integers are also false, so that values other than 1 are unchecked.
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "on", "yes", "1":
+		return true, nil
+	case "false", "off", "no", "0", "":
+		return false, nil
+	}
+
+	if _, err := strconv.ParseInt(value, 10, 0); err != nil {
+		return false, err
+	}
+
+	return false, nil
+}
+
+// decodeCollectionPtr decodes a pointer to a slice or map. A nil pointer is only allocated if a non-empty value is
+// submitted, and the pointer is set to nil if an empty value is submitted, so that absent collections round trip.
+func (h *HTTPDecoder) decodeCollectionPtr(ctx context.Context, val reflect.Value, key string, field StructField) error {
+	n := reflect.New(val.Type().Elem())
+
+	if !val.IsNil() {
+		n.Elem().Set(val.Elem())
+	}
+
+	if err := h.decode(ctx, n.Elem(), key, field); err != nil {
+		return err
+	}
+
+	if n.Elem().IsNil() {
+		val.Set(reflect.Zero(val.Type()))
+	} else {
+		val.Set(n)
+	}
+
+	return nil
+}
+
+// decodeScalarPtr decodes a pointer to a scalar or CustomDecoder, e.g. *int or *string. If the form has no value for
+// the pointer, it is left unchanged, so a nil pointer means that the value was not submitted. Unchecked checkboxes
+// are not submitted, so a non-nil pointer to a checkbox type is set to false instead. An empty value sets the pointer
+// to nil, and any other value is decoded into a newly allocated pointer.
+func (h *HTTPDecoder) decodeScalarPtr(ctx context.Context, val reflect.Value, key string, field StructField) error {
+	formValues := h.getFormValues(key)
+
+	if len(formValues) == 0 {
+		if !val.IsNil() && isCheckboxType(val.Type().Elem()) {
+			val.Set(reflect.New(val.Type().Elem()))
+		}
+
+		return nil
+	}
+
+	if len(formValues) == 1 && formValues[0] == "" {
+		val.Set(reflect.Zero(val.Type()))
+
+		return nil
+	}
+
+	n := reflect.New(val.Type().Elem())
+
+	if !val.IsNil() {
+		n.Elem().Set(val.Elem())
+	}
+
+	numValidationErrors := len(h.validationErrors[FormElementName(key)])
+
+	if err := h.decode(ctx, n.Elem(), key, field); err != nil {
+		return err
+	}
+
+	if len(h.validationErrors[FormElementName(key)]) > numValidationErrors && !h.setValueOnValidationError {
+		// the value failed validation, so it is not set.
+		return nil
+	}
+
+	val.Set(n)
+
+	return nil
+}
+
+// customDecoderType is the type of the CustomDecoder interface.
+var customDecoderType = reflect.TypeOf((*CustomDecoder)(nil)).Elem()
+
+// isCheckboxType determines if values of type t are rendered as a checkbox.
+func isCheckboxType(t reflect.Type) bool {
+	return t.Kind() == reflect.Bool || t == reflect.TypeOf(BoolNumber(0))
+}
+
+// decodeOptional decodes the form value of key into the OptionalValue val, using its SetValue method.
+func (h *HTTPDecoder) decodeOptional(ctx context.Context, val reflect.Value, key string, field StructField) error {
+	formValues := h.getFormValues(key)
+
+	if len(formValues) == 0 {
+		return nil
+	}
+
+	if len(formValues) == 1 && formValues[0] == "" {
+		// an empty value means that the optional value is absent.
+		val.Set(reflect.Zero(val.Type()))
+
+		return nil
+	}
+
+	setValue, err := optionalSetter(val)
+
+	if err != nil {
+		return err
+	}
+
+	value := reflect.New(setValue.Type().In(0)).Elem()
+	numValidationErrors := len(h.validationErrors[FormElementName(key)])
+
+	if err := h.decode(ctx, value, key, field); err != nil {
+		return err
+	}
+
+	if len(h.validationErrors[FormElementName(key)]) > numValidationErrors && !h.setValueOnValidationError {
+		// the value failed validation, so it is not set.
+		return nil
+	}
+
+	setValue.Call([]reflect.Value{value})
+
+	return nil
+}
+
+// decodeParsedValue decodes the form values of key into val using a registered ValueParser.
+func (h *HTTPDecoder) decodeParsedValue(ctx context.Context, val reflect.Value, key string, field StructField, parser ValueParser) error {
+	formValues := popAllFormValues(h.form, FormElementName(key))
+
+	if len(formValues) == 0 {
+		// as with other concrete types, fields which are not in the form are not decoded.
+		return nil
+	}
+
+	parsed, err := parser(formValues)
+
+	if err != nil {
+		return err
+	}
+
+	if !parsed.IsValid() {
+		return nil
+	}
+
+	if !parsed.Type().AssignableTo(val.Type()) {
+		return fmt.Errorf("%w: %s is not assignable to %s", ErrInvalidParsedValue, parsed.Type(), val.Type())
+	}
+
+	if !parsed.CanInterface() {
+		// validation is not possible
+		val.Set(parsed)
+		return nil
+	}
+
+	if ok, err := h.passedValidation(ctx, key, parsed.Interface(), field); ok && err == nil {
+		val.Set(parsed)
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// decodeText decodes the form value of key into val using val's encoding.TextUnmarshaler implementation.
+// An empty value decodes to the zero value of val's type, rather than being passed to UnmarshalText.
+func (h *HTTPDecoder) decodeText(ctx context.Context, val reflect.Value, key string, field StructField) error {
+	formValue, ok := PopFormValue(h.form, FormElementName(key))
+
+	if !ok {
+		// as with other concrete types, fields which are not in the form are not decoded.
+		return nil
+	}
+
+	p := reflect.New(val.Type())
+
+	if formValue != "" {
+		if err := p.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(formValue)); err != nil {
+			return newDecodeError(key, formValue, err)
+		}
+	}
+
+	if ok, err := h.passedValidation(ctx, key, p.Elem().Interface(), field); ok && err == nil {
+		val.Set(p.Elem())
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// submitted determines if the form contained a value for the form element name, or for any element nested within it.
+func (h *HTTPDecoder) submitted(name string) bool {
+	if _, ok := h.submittedForm[name]; ok {
+		return true
+	}
+
+	for key := range h.submittedForm {
+		if strings.HasPrefix(key, name+"[") || strings.HasPrefix(key, name+fieldSeparator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateAbsentRequiredWhen adds a validation error for a field which was not submitted, if its requiredwhen
+// condition is met. Browsers don't submit unchecked checkboxes or empty selections, and any client may omit a
+// field, so an absent field is treated as an empty one.
+func (h *HTTPDecoder) validateAbsentRequiredWhen(name string, field StructField) error {
+	conditionField, conditionValue, ok := field.RequiredWhen()
+
+	if !ok {
+		return nil
+	}
+
+	requiredIf := &RequiredIfValidator{Field: conditionField, Value: conditionValue}
+	requiredIf.SetForm(h.submittedForm)
+
+	if valid, message := requiredIf.Validate(nil); !valid {
+		return h.addValidationError(name, ValidationError{Error: message})
+	}
+
+	return nil
+}
+
+func (h *HTTPDecoder) passedValidation(ctx context.Context, key string, value interface{}, field StructField) (bool, error) {
+	ok := true
+
+	validators := h.getValidators(field.Validators())
+
+	if conditionField, conditionValue, isConditional := field.RequiredWhen(); isConditional && !field.absent {
+		// absent fields have already had their condition validated by validateAbsentRequiredWhen.
+		requiredIf := &RequiredIfValidator{Field: conditionField, Value: conditionValue}
+		requiredIf.SetForm(h.submittedForm)
+
+		validators = append(validators, requiredIf)
+	}
+
+	for _, validator := range validators {
+		var valid bool
+		var message string
+
+		if contextValidator, ok := validator.(ContextValidator); ok {
+			valid, message = contextValidator.ValidateContext(ctx, value)
+		} else {
+			valid, message = validator.Validate(value)
+		}
+
+		if err := ctx.Err(); err != nil {
+			// the validator may have failed due to cancellation, so its result can't be trusted.
+			return false, err
+		}
+
+		if !valid {
+			err := h.addValidationError(FormElementName(key), ValidationError{
+				Value: value,
+				Error: message,
+			})
+
+			if err != nil {
+				return ok, err
+			}
+
+			ok = false
+		}
+	}
+
+	return ok || h.setValueOnValidationError, nil
+}
+
+// readOnlyValidationMessage is the validation error used when the value of a read only field has been modified.
+const readOnlyValidationMessage = "This value cannot be changed"
+
+// verifyReadOnly checks that the value submitted for the read only element name matches its hidden mirror, and adds
+// a validation error if it does not. Elements without a mirror, such as checkboxes, are not checked. The field is
+// never decoded, so it is left unchanged whether or not the value was modified.
+func (h *HTTPDecoder) verifyReadOnly(name string) error {
+	mirror, ok := PopFormValue(h.form, name+readOnlyMirrorSuffix)
+	formValue, _ := PopFormValue(h.form, name)
+
+	if !ok || formValue == mirror {
+		return nil
+	}
+
+	// the original value is rendered back into the form, rather than the modified one.
+	return h.addValidationError(name, ValidationError{Value: mirror, Error: readOnlyValidationMessage})
+}
+
+// unescapeForm URL decodes each of the form values. Values which cannot be decoded are removed from the form,
+// and a validation error is added for them.
+func (h *HTTPDecoder) unescapeForm() error {
+	for name, values := range h.form {
+		var unescapedValues []string
+
+		for _, value := range values {
+			unescaped, err := url.QueryUnescape(value)
+
+			if err != nil {
+				if err := h.addValidationError(name, ValidationError{Value: value, Error: err.Error()}); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			unescapedValues = append(unescapedValues, unescaped)
+		}
+
+		h.form[name] = unescapedValues
+	}
+
+	return nil
+}
+
+// addValidationError adds a validation error for the form element with the given name.
+func (h *HTTPDecoder) addValidationError(name string, validationError ValidationError) error {
+	h.validationErrors[name] = append(h.validationErrors[name], validationError)
+
+	return h.validationStore.AddValidationError(name, validationError)
+}
+
+// decodeMap decodes map entries which are submitted as individual form values, named key[mapKey].
+// Each entry is parsed according to the value type of the map. Entries which cannot be parsed are
+// recorded as validation errors on the map field.
+func (h *HTTPDecoder) decodeMap(ctx context.Context, val reflect.Value, key string, mapKeys []string, field StructField) error {
+	m := reflect.MakeMapWithSize(val.Type(), len(mapKeys))
+	parseFailed := false
+
+	for _, mapKey := range mapKeys {
+		entry := reflect.New(val.Type().Elem()).Elem()
+
+		if ok, err := h.decodeEntry(ctx, entry, key, mapKey); err != nil {
+			return err
+		} else if !ok {
+			parseFailed = true
+			continue
+		}
+
+		m.SetMapIndex(reflect.ValueOf(mapKey).Convert(val.Type().Key()), entry)
+	}
+
+	if ok, err := h.passedValidation(ctx, key, m.Interface(), field); err != nil {
+		return err
+	} else if ok && (!parseFailed || h.setValueOnValidationError) {
+		val.Set(m)
+	}
+
+	return nil
+}
+
+// decodeSlice decodes slice elements which are submitted as individual form values, named key[index].
+// Elements are decoded in index order, and each element is decoded in the same way as a struct field,
+// so element types which implement CustomDecoder are passed the indexed key. Gaps in the indexes
+// are not preserved, e.g. key[0] and key[2] are decoded into a slice of length 2.
+func (h *HTTPDecoder) decodeSlice(ctx context.Context, val reflect.Value, key string, indexes []int, field StructField) error {
+	s := reflect.MakeSlice(val.Type(), 0, len(indexes))
+	parseFailed := false
+
+	for _, index := range indexes {
+		entry := reflect.New(val.Type().Elem()).Elem()
+
+		if ok, err := h.decodeEntry(ctx, entry, key, strconv.Itoa(index)); err != nil {
+			return err
+		} else if !ok {
+			parseFailed = true
+			continue
+		}
+
+		s = reflect.Append(s, entry)
+	}
+
+	if ok, err := h.passedValidation(ctx, key, s.Interface(), field); err != nil {
+		return err
+	} else if ok && (!parseFailed || h.setValueOnValidationError) {
+		val.Set(s)
+	}
+
+	return nil
+}
+
+// decodeMultipleValues decodes all of the form values for key into the slice val. This is used for multiple Selects
+// and CheckboxGroups, where each selected or checked value is sent as a separate form value (regardless of the
+// optgroup that a Select's option is in).
+func (h *HTTPDecoder) decodeMultipleValues(ctx context.Context, val reflect.Value, key string, field StructField) error {
+	name := FormElementName(key)
+	formValues, ok := h.form[name]
+
+	if !ok {
+		return nil
+	}
+
+	delete(h.form, name)
+
+	s := reflect.MakeSlice(val.Type(), 0, len(formValues))
+	_, isCheckboxGroup := val.Interface().(CheckboxGroup)
+
+	for _, formValue := range formValues {
+		if isCheckboxGroup && formValue == checkboxGroupSentinel {
+			// the sentinel is submitted even if no options are checked, in which case the slice is empty.
+			continue
+		}
+
+		entry := reflect.New(val.Type().Elem()).Elem()
+
+		if err := setBasicValue(entry, formValue); err != nil {
+			return newDecodeError(key, formValue, err)
+		}
+
+		s = reflect.Append(s, entry)
+	}
+
+	if ok, err := h.passedValidation(ctx, key, s.Interface(), field); ok && err == nil {
+		val.Set(s)
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// decodeFlags decodes the selected flags of key into val, an integer Flags type, by combining them with a bitwise OR.
+func (h *HTTPDecoder) decodeFlags(ctx context.Context, val reflect.Value, key string, field StructField, flags []Flag) error {
+	formValues := popAllFormValues(h.form, FormElementName(key))
+
+	if len(formValues) == 0 {
+		// as with other concrete types, fields which are not in the form are not decoded.
+		return nil
+	}
+
+	var value uint64
+
+	for _, formValue := range formValues {
+		if formValue == flagsSentinel {
+			// the sentinel is submitted even if no flags are selected, in which case the value is 0.
+			continue
+		}
+
+		flag, err := strconv.ParseUint(formValue, 10, 64)
+
+		if err != nil || !isFlag(flags, flag) {
+			return newDecodeError(key, formValue, ErrInvalidFlag)
+		}
+
+		value |= flag
+	}
+
+	decoded := reflect.New(val.Type()).Elem()
+
+	switch decoded.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		decoded.SetInt(int64(value))
+	default:
+		decoded.SetUint(value)
+	}
+
+	if ok, err := h.passedValidation(ctx, key, decoded.Interface(), field); ok && err == nil {
+		val.Set(decoded)
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// setBasicValue parses s into val, which must be a string, bool or numeric kind.
+func setBasicValue(val reflect.Value, s string) error {
+	switch val.Kind() {
+	case reflect.String:
+		val.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, val.Type().Bits())
+
+		if err != nil {
+			return err
+		}
+
+		val.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(s, 10, val.Type().Bits())
+
+		if err != nil {
+			return err
+		}
+
+		val.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, val.Type().Bits())
+
+		if err != nil {
+			return err
+		}
+
+		val.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+
+		if err != nil {
+			return err
+		}
+
+		val.SetBool(b)
+	default:
+		return fmt.Errorf("formulate: cannot decode %q into %s", s, val.Type())
+	}
+
+	return nil
+}
+
+// decodeEntry decodes the map entry or slice element entryKey of the field with the given key into entry.
+// Entries which cannot be parsed are recorded as validation errors on the field, and false is returned.
+func (h *HTTPDecoder) decodeEntry(ctx context.Context, entry reflect.Value, key, entryKey string) (bool, error) {
+	err := h.decode(ctx, entry, mapEntryName(key, entryKey), StructField{})
+
+	if err == nil {
+		return true, nil
+	}
+
+	var numErr *strconv.NumError
+
+	if !errors.As(err, &numErr) {
+		return false, err
+	}
+
+	err = h.addValidationError(FormElementName(key), ValidationError{
+		Value: numErr.Num,
+		Error: fmt.Sprintf("%s: invalid value %q", entryKey, numErr.Num),
+	})
+
+	return false, err
+}
+
+// stripElementNamePrefix returns the values of form whose names begin with prefix, with the prefix removed.
+func stripElementNamePrefix(form url.Values, prefix string) url.Values {
+	stripped := make(url.Values, len(form))
+
+	for name, values := range form {
+		if strings.HasPrefix(name, prefix) {
+			stripped[strings.TrimPrefix(name, prefix)] = values
+		}
+	}
+
+	return stripped
+}
+
+// convertBracketNotation returns a copy of form in which the bracket notation names of the nested struct fields of t
+// are converted to dotted names, e.g. Address[HouseName] becomes Address.HouseName.
+func convertBracketNotation(form url.Values, t reflect.Type) url.Values {
+	paths := make(map[string]string)
+	nestedStructPaths(t, "", "", paths, make(map[reflect.Type]bool))
+
+	converted := make(url.Values, len(form))
+
+	for key, values := range form {
+		name := key
+
+		// find the longest prefix of the key, ending in a bracket, which is the name of a nested struct field.
+		for i := len(key) - 1; i >= 0; i-- {
+			if key[i] != ']' {
+				continue
+			}
+
+			if dotted, ok := paths[key[:i+1]]; ok {
+				name = dotted + key[i+1:]
+				break
+			}
+		}
+
+		converted[name] = append(converted[name], values...)
+	}
+
+	return converted
+}
+
+// nestedStructPaths adds the bracket notation names of the fields of nested structs within t to paths, mapped to
+// their dotted names. visited prevents recursive types from being walked indefinitely.
+func nestedStructPaths(t reflect.Type, bracketName, dottedName string, paths map[string]string, visited map[reflect.Type]bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || visited[t] {
+		return
+	}
+
+	visited[t] = true
+	defer delete(visited, t)
+
+	for _, structField := range cachedStructFields(t) {
+		if !structField.IsExported() {
+			continue
+		}
+
+		fieldBracketName, fieldDottedName := structField.Name, structField.Name
+
+		if dottedName != "" {
+			fieldBracketName = bracketName + "[" + structField.Name + "]"
+			fieldDottedName = dottedName + fieldSeparator + structField.Name
+			paths[fieldBracketName] = fieldDottedName
+		}
+
+		nestedStructPaths(structField.Type, fieldBracketName, fieldDottedName, paths, visited)
+	}
+}
+
+// mapEntryName is the name of the form element for the entry mapKey of the map with the given key.
+func mapEntryName(key, mapKey string) string {
+	return key + "[" + mapKey + "]"
+}
+
+// mapFormKeys returns the sorted keys of the map entries in the form for the map with the given key.
+// Only maps with string keys are decoded from individual entries.
+func mapFormKeys(form url.Values, val reflect.Value, key string) []string {
+	if val.Type().Key().Kind() != reflect.String {
+		return nil
+	}
+
+	var mapKeys []string
+
+	prefix := FormElementName(key) + "["
+
+	for formKey := range form {
+		if strings.HasPrefix(formKey, prefix) && strings.HasSuffix(formKey, "]") {
+			mapKeys = append(mapKeys, strings.TrimSuffix(strings.TrimPrefix(formKey, prefix), "]"))
+		}
+	}
+
+	sort.Strings(mapKeys)
+
+	return mapKeys
+}
+
+// sliceFormIndexes returns the sorted indexes of the slice elements in the form for the slice with the given key.
+// Elements may be submitted as key[index], or as key[index].Field for slices of structs.
+func sliceFormIndexes(form url.Values, key string) []int {
+	seen := make(map[int]bool)
+
+	var indexes []int
+
+	prefix := FormElementName(key) + "["
+
+	for formKey := range form {
+		if !strings.HasPrefix(formKey, prefix) {
+			continue
+		}
+
+		rest := strings.TrimPrefix(formKey, prefix)
+		end := strings.IndexByte(rest, ']')
+
+		if end < 0 || (rest[end+1:] != "" && !strings.HasPrefix(rest[end+1:], fieldSeparator)) {
+			continue
+		}
+
+		index, err := strconv.Atoi(rest[:end])
+
+		if err != nil || index < 0 || seen[index] {
+			continue
+		}
+
+		seen[index] = true
+		indexes = append(indexes, index)
+	}
+
+	sort.Ints(indexes)
+
+	return indexes
+}
+
+// popAllFormValues takes all of the remaining values for key from the form.
+func popAllFormValues(form url.Values, key string) []string {
+	formValues := form[key]
+
+	if len(formValues) > 0 {
+		form[key] = []string{}
+	}
+
+	return formValues
+}
+
+// containsString determines if s is one of values.
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+
+	return false
 }
 
 // PopFormValue takes a value from the form and removes it so that it is not parsed again.
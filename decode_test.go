@@ -1,9 +1,17 @@
 package formulate
 
 import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/big"
+	"net"
 	"net/url"
 	"reflect"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
@@ -91,7 +99,7 @@ It spans multiple lines`
 		dec.AddValidators(&minAgeValidator{min: 20})
 		dec.AddValidators(countryCodeValidator{})
 
-		if err := dec.Decode(&details); err != ErrFormFailedValidation {
+		if err := dec.Decode(&details); !errors.Is(err, ErrFormFailedValidation) {
 			t.Fail()
 		}
 
@@ -102,115 +110,1845 @@ It spans multiple lines`
 		}
 	})
 
+	t.Run("Validation fails with ValidationErrors", func(t *testing.T) {
+		vals.Set("CountryCode", "uk")
+
+		dec := NewDecoder(vals)
+		dec.AddValidators(&minAgeValidator{min: 20})
+		dec.AddValidators(countryCodeValidator{})
+
+		err := dec.Decode(&details)
+
+		var validationErrors ValidationErrors
+
+		if !errors.As(err, &validationErrors) {
+			t.Errorf("Expected ValidationErrors, got: %v", err)
+			return
+		}
+
+		assertEquals(t, len(validationErrors), 1)
+		assertEquals(t, len(validationErrors["CountryCode"]), 1)
+		assertEquals(t, validationErrors["CountryCode"][0].Value, "uk")
+	})
+
 	t.Run("Decode on non-ptr type", func(t *testing.T) {
 		dec := NewDecoder(nil)
 
-		out := struct{}{}
+		out := struct{}{}
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Expected panic() on non-ptr type.")
+			}
+		}()
+
+		_ = dec.Decode(out)
+	})
+
+	t.Run("Decode on non-struct type", func(t *testing.T) {
+		dec := NewDecoder(nil)
+
+		var out int
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Expected panic() on non-struct type.")
+			}
+		}()
+
+		_ = dec.Decode(&out)
+	})
+
+	t.Run("Custom decoder", func(t *testing.T) {
+		dec := NewDecoder(url.Values{"CustomValue": {"1", "7", "33"}})
+
+		var out customDecoderTest
+
+		if err := dec.Decode(&out); err != nil {
+			t.Error(err)
+		}
+
+		if out[0] != 1 && out[1] != 7 && out[2] != 33 {
+			t.Fail()
+		}
+	})
+
+	t.Run("Decode into interface{} as struct field", func(t *testing.T) {
+		type test struct {
+			Data interface{}
+		}
+
+		x := test{Data: "this is a string"}
+
+		dec := NewDecoder(url.Values{"Data": {"new string"}})
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+		}
+
+		assertEquals(t, x.Data, "new string")
+	})
+
+	t.Run("Decode without losing value in interface{}", func(t *testing.T) {
+		type testData struct {
+			Value string
+		}
+
+		type test struct {
+			Data interface{}
+		}
+
+		x := test{Data: &testData{Value: "this is a string"}}
+
+		dec := NewDecoder(url.Values{})
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if x.Data == nil {
+			t.Fail()
+			return
+		}
+
+		v, ok := x.Data.(*testData)
+
+		if !ok || v == nil {
+			t.Fail()
+			return
+		}
+
+		assertEquals(t, v.Value, "this is a string")
+	})
+
+	t.Run("Decode where empty value overwrites already set value in struct", func(t *testing.T) {
+		type testData struct {
+			Value string
+		}
+
+		x := testData{Value: "this is a string"}
+
+		dec := NewDecoder(url.Values{"Value": []string{""}})
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if x.Value != "" {
+			t.Fail()
+			return
+		}
+	})
+
+	t.Run("Validators are skipped for hidden fields", func(t *testing.T) {
+		type test struct {
+			Name        string
+			CountryCode string `show:"invisible" validators:"countryCode"`
+			Country     string `validators:"countryCode"`
+		}
+
+		var x test
+
+		dec := NewDecoder(url.Values{"Name": {"John Smith"}, "CountryCode": {"uk"}, "Country": {"uk"}})
+		dec.AddValidators(countryCodeValidator{})
+		dec.AddShowCondition("invisible", func(field StructField) bool {
+			return false
+		})
+		dec.AddGlobalShowCondition(func(field StructField) bool {
+			return field.Name != "Country"
+		})
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, x.Name, "John Smith")
+		assertEquals(t, x.CountryCode, "")
+		assertEquals(t, x.Country, "")
+	})
+
+	t.Run("Decode map entries into typed maps", func(t *testing.T) {
+		type test struct {
+			Scores   map[string]int
+			Features map[string]bool
+		}
+
+		var x test
+
+		dec := NewDecoder(url.Values{
+			"Scores[alice]":  {"5"},
+			"Scores[bob]":    {"3"},
+			"Features[beta]": {"on"},
+			"Features[dark]": {"0"},
+		})
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, len(x.Scores), 2)
+		assertEquals(t, x.Scores["alice"], 5)
+		assertEquals(t, x.Scores["bob"], 3)
+		assertEquals(t, x.Features["beta"], true)
+		assertEquals(t, x.Features["dark"], false)
+	})
+
+	t.Run("Decode unchecked map entries", func(t *testing.T) {
+		type test struct {
+			Features map[string]bool `elem:"map"`
+		}
+
+		x := test{Features: map[string]bool{"beta": true, "dark": true}}
+
+		// only the hidden fallback values are submitted when every checkbox is unchecked.
+		if err := NewDecoder(url.Values{"Features[beta]": {"false"}, "Features[dark]": {"false"}}).Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, len(x.Features), 2)
+		assertEquals(t, x.Features["beta"], false)
+		assertEquals(t, x.Features["dark"], false)
+	})
+
+	t.Run("Decode map entries with unparseable values", func(t *testing.T) {
+		type test struct {
+			Scores   map[string]int
+			Features map[string]bool
+		}
+
+		x := test{Scores: map[string]int{"alice": 1}}
+
+		dec := NewDecoder(url.Values{
+			"Scores[alice]":  {"5"},
+			"Scores[bob]":    {"three"},
+			"Features[beta]": {"yes please"},
+		})
+
+		err := dec.Decode(&x)
+
+		var validationErrors ValidationErrors
+
+		if !errors.As(err, &validationErrors) {
+			t.Errorf("Expected ValidationErrors, got: %v", err)
+			return
+		}
+
+		assertEquals(t, len(validationErrors["Scores"]), 1)
+		assertEquals(t, validationErrors["Scores"][0].Value, "three")
+		assertEquals(t, len(validationErrors["Features"]), 1)
+
+		// the map is not set, as it failed to parse.
+		assertEquals(t, x.Scores["alice"], 1)
+	})
+
+	t.Run("Decode read only field", func(t *testing.T) {
+		type test struct {
+			Username string `readonly:"true"`
+		}
+
+		x := test{Username: "jane"}
+
+		dec := NewDecoder(url.Values{"Username": {"jane"}, "Username.readonly": {"jane"}})
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, x.Username, "jane")
+	})
+
+	t.Run("Decode tampered read only field", func(t *testing.T) {
+		type test struct {
+			Username string `readonly:"true"`
+			Agree    bool   `readonly:"true"`
+		}
+
+		x := test{Username: "jane"}
+
+		dec := NewDecoder(url.Values{"Username": {"admin"}, "Username.readonly": {"jane"}, "Agree": {"on"}})
+
+		err := dec.Decode(&x)
+
+		var validationErrors ValidationErrors
+
+		if !errors.As(err, &validationErrors) {
+			t.Errorf("Expected ValidationErrors, got: %v", err)
+			return
+		}
+
+		assertEquals(t, len(validationErrors["Username"]), 1)
+		assertEquals(t, validationErrors["Username"][0].Error, readOnlyValidationMessage)
+		assertEquals(t, validationErrors["Username"][0].Value, "jane")
+		assertEquals(t, len(validationErrors["Agree"]), 0)
+		assertEquals(t, x.Username, "jane")
+		assertEquals(t, x.Agree, false)
+	})
+
+	t.Run("Decode with context", func(t *testing.T) {
+		type test struct {
+			CountryCode string `validators:"contextCountryCode"`
+		}
+
+		var x test
+
+		ctx, cancel := context.WithCancel(context.Background())
+		validator := &contextCountryCodeValidator{cancel: cancel}
+
+		dec := NewDecoder(url.Values{"CountryCode": {"GBR"}})
+		dec.AddValidators(validator)
+
+		if err := dec.DecodeContext(ctx, &x); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got: %v", err)
+		}
+
+		assertEquals(t, validator.ctx, ctx)
+		assertEquals(t, x.CountryCode, "")
+
+		if err := NewDecoder(url.Values{"CountryCode": {"GBR"}}).DecodeContext(ctx, &x); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got: %v", err)
+		}
+	})
+
+	t.Run("Decode URL encoded values", func(t *testing.T) {
+		type test struct {
+			Name        string
+			Description string
+			Age         int
+		}
+
+		var x test
+
+		dec := NewDecoder(url.Values{"Name": {"John%20Smith"}, "Description": {"100%25+cotton"}, "Age": {"%33%30"}})
+		dec.SetURLDecodeValues(true)
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, x.Name, "John Smith")
+		assertEquals(t, x.Description, "100% cotton")
+		assertEquals(t, x.Age, 30)
+	})
+
+	t.Run("Decode invalid URL encoded values", func(t *testing.T) {
+		type test struct {
+			Name string
+		}
+
+		x := test{Name: "Jane"}
+
+		dec := NewDecoder(url.Values{"Name": {"John%zzSmith"}})
+		dec.SetURLDecodeValues(true)
+
+		err := dec.Decode(&x)
+
+		var validationErrors ValidationErrors
+
+		if !errors.As(err, &validationErrors) {
+			t.Errorf("Expected ValidationErrors, got: %v", err)
+			return
+		}
+
+		assertEquals(t, len(validationErrors["Name"]), 1)
+		assertEquals(t, x.Name, "Jane")
+	})
+
+	t.Run("Decode SuggestText", func(t *testing.T) {
+		type test struct {
+			Colour SuggestText
+		}
+
+		x := test{Colour: NewSuggestText("red", "red", "green", "blue")}
+
+		dec := NewDecoder(url.Values{"Colour": {"purple"}})
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, x.Colour.String(), "purple")
+		assertEquals(t, len(x.Colour.DatalistOptions()), 3)
+	})
+
+	t.Run("Decode time formats", func(t *testing.T) {
+		type test struct {
+			Birthday time.Time `format:"date"`
+			Alarm    time.Time `format:"time"`
+			Expiry   time.Time `format:"month"`
+		}
+
+		var x test
+
+		dec := NewDecoder(url.Values{"Birthday": {"2020-05-28"}, "Alarm": {"15:28"}, "Expiry": {"2020-05"}})
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, x.Birthday.Format(time.RFC3339), "2020-05-28T00:00:00Z")
+		assertEquals(t, x.Alarm.Format("15:04"), "15:28")
+		assertEquals(t, x.Expiry.Format(time.RFC3339), "2020-05-01T00:00:00Z")
+	})
+
+	t.Run("Decode indexed slice of custom decoders", func(t *testing.T) {
+		type test struct {
+			Codes []upperCaseDecoder
+			Ports []int
+		}
+
+		var x test
+
+		dec := NewDecoder(url.Values{
+			"Codes[0]": {"gbr"},
+			"Codes[2]": {"usa"},
+			"Codes[1]": {"fra"},
+			"Ports[0]": {"80"},
+			"Ports[1]": {"443"},
+		})
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, len(x.Codes), 3)
+		assertEquals(t, x.Codes[0], upperCaseDecoder("GBR"))
+		assertEquals(t, x.Codes[1], upperCaseDecoder("FRA"))
+		assertEquals(t, x.Codes[2], upperCaseDecoder("USA"))
+		assertEquals(t, len(x.Ports), 2)
+		assertEquals(t, x.Ports[1], 443)
+	})
+
+	t.Run("Decode skips unsupported kinds", func(t *testing.T) {
+		type test struct {
+			Name     string
+			Callback func() string
+			Events   chan int
+			Phase    complex128
+			Handlers struct {
+				OnSave func() error
+			}
+		}
+
+		callback := func() string { return "called" }
+		events := make(chan int)
+		x := test{Callback: callback, Events: events, Phase: 1 + 2i}
+
+		dec := NewDecoder(url.Values{
+			"Name":            {"Jane"},
+			"Callback":        {"func"},
+			"Events":          {"1"},
+			"Phase":           {"3+4i"},
+			"Handlers.OnSave": {"func"},
+		})
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, x.Name, "Jane")
+		assertEquals(t, x.Callback(), "called")
+		assertEquals(t, x.Events, events)
+		assertEquals(t, x.Phase, 1+2i)
+
+		if x.Handlers.OnSave != nil {
+			t.Error("Expected OnSave to be skipped")
+		}
+	})
+
+	t.Run("Decode records custom decoder errors as validation errors", func(t *testing.T) {
+		type test struct {
+			Code   upperCaseDecoder
+			Broken failingDecoder
+		}
+
+		var x test
+
+		form := url.Values{"Code": {"gbr"}, "Broken": {"oops"}}
+		dec := NewDecoder(form)
+
+		var decodeErr *DecodeError
+
+		if err := dec.Decode(&x); !errors.As(err, &decodeErr) {
+			t.Errorf("Expected custom decoder error to abort decoding, got: %v", err)
+			return
+		}
+
+		assertEquals(t, decodeErr.Field, "Broken")
+		assertEquals(t, decodeErr.Value, "oops")
+		assertEquals(t, decodeErr.Unwrap().Error(), "invalid value")
+
+		// custom decoder errors are not parse failures, so they are unaffected by SetInvalidInputAsValidationError.
+		x = test{}
+		form = url.Values{"Code": {"gbr"}, "Broken": {"oops"}}
+		dec = NewDecoder(form)
+		dec.SetInvalidInputAsValidationError(true)
+
+		if err := dec.Decode(&x); !errors.As(err, &decodeErr) {
+			t.Errorf("Expected custom decoder error to abort decoding, got: %v", err)
+			return
+		}
+
+		x = test{}
+		form = url.Values{"Code": {"gbr"}, "Broken": {"oops"}}
+		dec = NewDecoder(form)
+		dec.SetInvalidInputAsValidationError(true)
+		dec.SetCustomDecoderErrorsAsValidation(true)
+
+		var validationErrors ValidationErrors
+
+		if err := dec.Decode(&x); !errors.As(err, &validationErrors) {
+			t.Errorf("Expected ValidationErrors, got: %v", err)
+			return
+		}
+
+		assertEquals(t, x.Code, upperCaseDecoder("GBR"))
+		assertEquals(t, len(validationErrors["Broken"]), 1)
+		assertEquals(t, validationErrors["Broken"][0].Error, "invalid value")
+		assertEquals(t, validationErrors["Broken"][0].Value, "oops")
+	})
+
+	t.Run("Decode grouped multiple select", func(t *testing.T) {
+		type test struct {
+			Produce groupedSelect
+			Numbers numberIndexedSelect
+		}
+
+		var x test
+
+		dec := NewDecoder(url.Values{"Produce": {"banana", "carrot", "none"}, "Numbers": {"1", "3"}})
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, len(x.Produce), 3)
+		assertEquals(t, x.Produce[0], "banana")
+		assertEquals(t, x.Produce[1], "carrot")
+		assertEquals(t, x.Produce[2], "none")
+		assertEquals(t, len(x.Numbers), 2)
+		assertEquals(t, x.Numbers[1], 3)
+	})
+
+	t.Run("Decode checkbox with hidden fallback value", func(t *testing.T) {
+		type test struct {
+			Agree     bool
+			Subscribe bool
+			Legacy    BoolNumber
+			Declined  bool
+		}
+
+		var x test
+
+		dec := NewDecoder(url.Values{
+			"Agree":     {"0", "on"},
+			"Subscribe": {"0", "1"},
+			"Legacy":    {"0", "on"},
+			"Declined":  {"0"},
+		})
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, x.Agree, true)
+		assertEquals(t, x.Subscribe, true)
+		assertEquals(t, x.Legacy, BoolNumber(1))
+		assertEquals(t, x.Declined, false)
+	})
+
+	t.Run("Decode unchecked BoolNumber", func(t *testing.T) {
+		type test struct {
+			Name   string
+			Legacy BoolNumber
+		}
+
+		x := test{Legacy: 1}
+
+		if err := NewDecoder(url.Values{"Name": {"Dave"}}).Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, "Dave", x.Name)
+		assertEquals(t, BoolNumber(0), x.Legacy)
+
+		value, err := BoolNumber(0).DecodeFormValue(url.Values{}, "Legacy", nil)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, BoolNumber(0), value.Interface())
+	})
+
+	t.Run("Decode boolean tokens", func(t *testing.T) {
+		type test struct {
+			Enabled bool
+			Legacy  BoolNumber
+		}
+
+		for token, expected := range map[string]bool{
+			"on": true, "ON": true, "1": true, "true": true, "True": true, "yes": true, "YES": true,
+			"off": false, "0": false, "false": false, "FALSE": false, "no": false, "": false, "2": false,
+		} {
+			x := test{Enabled: !expected}
+
+			dec := NewDecoder(url.Values{"Enabled": {token}, "Legacy": {token}})
+
+			if err := dec.Decode(&x); err != nil {
+				t.Errorf("%q: %v", token, err)
+				continue
+			}
+
+			if x.Enabled != expected || x.Legacy.Bool() != expected {
+				t.Errorf("%q: expected %t, got %t and %t", token, expected, x.Enabled, x.Legacy.Bool())
+			}
+		}
+
+		var x test
+
+		var decodeErr *DecodeError
+
+		if err := NewDecoder(url.Values{"Enabled": {"maybe"}}).Decode(&x); !errors.As(err, &decodeErr) {
+			t.Errorf("Expected DecodeError for an invalid boolean, got: %v", err)
+		}
+	})
+
+	t.Run("Decode checkbox group", func(t *testing.T) {
+		type test struct {
+			Toppings toppings
+		}
+
+		x := test{Toppings: toppings{"ham"}}
+
+		dec := NewDecoder(url.Values{"Toppings": {"", "cheese", "olives"}})
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, len(x.Toppings), 2)
+		assertEquals(t, x.Toppings[0], "cheese")
+		assertEquals(t, x.Toppings[1], "olives")
+	})
+
+	t.Run("Decode checkbox group with no checked options", func(t *testing.T) {
+		type test struct {
+			Toppings toppings
+		}
+
+		x := test{Toppings: toppings{"ham"}}
+
+		if err := NewDecoder(url.Values{"Toppings": {""}}).Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, len(x.Toppings), 0)
+	})
+
+	t.Run("Decode nullable select", func(t *testing.T) {
+		type test struct {
+			Pet      *Pet
+			OtherPet *Pet
+		}
+
+		dog := Pet("dog")
+		x := test{Pet: &dog}
+
+		dec := NewDecoder(url.Values{"Pet": {""}, "OtherPet": {"cat"}})
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if x.Pet != nil {
+			t.Errorf("Expected Pet to be nil, got: %v", *x.Pet)
+		}
+
+		if x.OtherPet == nil {
+			t.Error("Expected OtherPet to be set")
+			return
+		}
+
+		assertEquals(t, *x.OtherPet, Pet("cat"))
+	})
+
+	t.Run("Decode month and week", func(t *testing.T) {
+		type test struct {
+			Month     Month
+			FirstWeek Week
+			LastWeek  Week
+		}
+
+		var x test
+
+		dec := NewDecoder(url.Values{"Month": {"2020-05"}, "FirstWeek": {"2020-W01"}, "LastWeek": {"2020-W53"}})
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, x.Month.Format(time.RFC3339), "2020-05-01T00:00:00Z")
+		assertEquals(t, x.FirstWeek.Format(time.RFC3339), "2019-12-30T00:00:00Z")
+		assertEquals(t, x.LastWeek.Format(time.RFC3339), "2020-12-28T00:00:00Z")
+
+		for _, invalid := range []string{"2021-W53", "2020-W00", "2020-05", "2020-W1"} {
+			dec = NewDecoder(url.Values{"LastWeek": {invalid}})
+
+			if err := dec.Decode(&x); !errors.Is(err, ErrInvalidWeek) {
+				t.Errorf("Expected ErrInvalidWeek for %s, got: %v", invalid, err)
+			}
+		}
+	})
+
+	t.Run("Round trip month and week", func(t *testing.T) {
+		type test struct {
+			Month Month
+			Week  Week
+		}
+
+		for _, tm := range []time.Time{
+			time.Date(2021, time.January, 3, 0, 0, 0, 0, time.UTC),   // Sunday of 2020-W53
+			time.Date(2021, time.January, 4, 0, 0, 0, 0, time.UTC),   // Monday of 2021-W01
+			time.Date(2019, time.December, 30, 0, 0, 0, 0, time.UTC), // Monday of 2020-W01
+		} {
+			form := url.Values{
+				"Month": {formatTimeValue(tm, "month")},
+				"Week":  {formatTimeValue(tm, "week")},
+			}
+
+			var x test
+
+			if err := NewDecoder(form).Decode(&x); err != nil {
+				t.Error(err)
+				return
+			}
+
+			y, w := tm.ISOWeek()
+			decodedYear, decodedWeek := x.Week.ISOWeek()
+
+			assertEquals(t, decodedYear, y)
+			assertEquals(t, decodedWeek, w)
+			assertEquals(t, x.Week.Weekday(), time.Monday)
+			assertEquals(t, x.Month.Year(), tm.Year())
+			assertEquals(t, x.Month.Month(), tm.Month())
+		}
+	})
+
+	t.Run("Parse errors record the field and value", func(t *testing.T) {
+		type address struct {
+			HouseNumber int
+		}
+
+		type test struct {
+			Address address
+		}
+
+		dec := NewDecoder(url.Values{
+			"Address.HouseNumber": {"abc"},
+		})
+
+		err := dec.Decode(&test{})
+
+		var decodeErr *DecodeError
+
+		if !errors.As(err, &decodeErr) {
+			t.Errorf("Expected DecodeError, got: %v", err)
+			return
+		}
+
+		assertEquals(t, "Address.HouseNumber", decodeErr.Field)
+		assertEquals(t, "abc", decodeErr.Value)
+
+		var numErr *strconv.NumError
+
+		if !errors.As(errors.Unwrap(err), &numErr) {
+			t.Errorf("Expected unwrapped error to be a *strconv.NumError, got: %v", errors.Unwrap(err))
+		}
+	})
+
+	t.Run("Disabled fields and structs are not decoded", func(t *testing.T) {
+		type card struct {
+			Number string
+		}
+
+		type billing struct {
+			Name string
+			Card card
+		}
+
+		type test struct {
+			Billing billing `disabled:"true"`
+			Notes   string  `disabled:"true"`
+			Email   string
+		}
+
+		dec := NewDecoder(url.Values{
+			"Billing.Name":        {"Mallory"},
+			"Billing.Card.Number": {"4111111111111111"},
+			"Notes":               {"changed"},
+			"Email":               {"user@example.com"},
+		})
+
+		x := test{Billing: billing{Name: "Alice", Card: card{Number: "1234"}}, Notes: "original"}
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, "Alice", x.Billing.Name)
+		assertEquals(t, "1234", x.Billing.Card.Number)
+		assertEquals(t, "original", x.Notes)
+		assertEquals(t, "user@example.com", x.Email)
+	})
+
+	t.Run("Wrong shaped JSON as validation errors", func(t *testing.T) {
+		type test struct {
+			Tags     []string
+			Name     string
+			Switches []bool
+		}
+
+		dec := NewDecoder(url.Values{
+			"Tags":     {`{"colour": "red"}`},
+			"Name":     {"Dave"},
+			"Switches": {`["on"]`},
+		})
+		dec.SetInvalidInputAsValidationError(true)
+
+		x := test{Tags: []string{"blue"}}
+
+		err := dec.Decode(&x)
+
+		var validationErrors ValidationErrors
+
+		if !errors.As(err, &validationErrors) {
+			t.Errorf("Expected ValidationErrors, got: %v", err)
+			return
+		}
+
+		if len(validationErrors["Tags"]) != 1 {
+			t.Errorf("Expected one validation error for Tags, got: %v", validationErrors)
+			return
+		}
+
+		assertEquals(t, `{"colour": "red"}`, validationErrors["Tags"][0].Value)
+		assertEquals(t, "This value must be a JSON array, not a JSON object", validationErrors["Tags"][0].Error)
+		assertEquals(t, "This value must be a JSON boolean, not a JSON string", validationErrors["Switches"][0].Error)
+		assertEquals(t, 1, len(x.Tags))
+		assertEquals(t, "blue", x.Tags[0])
+		assertEquals(t, "Dave", x.Name)
+	})
+
+	t.Run("Parse errors as validation errors", func(t *testing.T) {
+		type address struct {
+			HouseNumber int
+			Street      string
+		}
+
+		type test struct {
+			Age     int
+			Born    time.Time `format:"date"`
+			Address address
+			Name    string
+		}
+
+		dec := NewDecoder(url.Values{
+			"Age":                 {"abc"},
+			"Born":                {"yesterday"},
+			"Address.HouseNumber": {"1a"},
+			"Address.Street":      {"Example Road"},
+			"Name":                {"Dave"},
+		})
+		dec.SetInvalidInputAsValidationError(true)
+
+		x := test{Age: 30}
+
+		err := dec.Decode(&x)
+
+		var validationErrors ValidationErrors
+
+		if !errors.As(err, &validationErrors) {
+			t.Errorf("Expected ValidationErrors, got: %v", err)
+			return
+		}
+
+		for field, value := range map[string]string{"Age": "abc", "Born": "yesterday", "Address.HouseNumber": "1a"} {
+			if len(validationErrors[field]) != 1 {
+				t.Errorf("Expected one validation error for %s, got: %v", field, validationErrors)
+				continue
+			}
+
+			assertEquals(t, value, validationErrors[field][0].Value)
+			assertEquals(t, InvalidInputValidationMessage, validationErrors[field][0].Error)
+		}
+
+		assertEquals(t, 30, x.Age)
+		assertEquals(t, "Example Road", x.Address.Street)
+		assertEquals(t, "Dave", x.Name)
+	})
+
+	t.Run("Round trip pointers to slices", func(t *testing.T) {
+		type test struct {
+			Tags *[]string
+		}
+
+		for _, tags := range []*[]string{nil, {"red", "green"}} {
+			in := test{Tags: tags}
+
+			buf := new(bytes.Buffer)
+
+			if err := NewEncoder(buf, nil, nil).Encode(&in); err != nil {
+				t.Error(err)
+				return
+			}
+
+			if tags == nil {
+				assertEquals(t, true, in.Tags == nil)
+
+				if !strings.Contains(buf.String(), `<textarea name="Tags" id="Tags"></textarea>`) {
+					t.Errorf("Expected empty textarea for nil Tags, got: %s", buf.String())
+				}
+			}
+
+			b, err := json.MarshalIndent(tags, "", "  ")
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			form := url.Values{"Tags": {""}}
+
+			if tags != nil {
+				form.Set("Tags", string(b))
+			}
+
+			var out test
+
+			if err := NewDecoder(form).Decode(&out); err != nil {
+				t.Error(err)
+				return
+			}
+
+			if tags == nil {
+				assertEquals(t, true, out.Tags == nil)
+			} else if out.Tags == nil || len(*out.Tags) != 2 || (*out.Tags)[0] != "red" || (*out.Tags)[1] != "green" {
+				t.Errorf("Expected Tags to round trip, got: %v", out.Tags)
+			}
+		}
+	})
+
+	t.Run("Pointers to slices are not allocated without a value", func(t *testing.T) {
+		type test struct {
+			Name string
+			Tags *[]string
+		}
+
+		var out test
+
+		if err := NewDecoder(url.Values{"Name": {"Dave"}}).Decode(&out); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, "Dave", out.Name)
+		assertEquals(t, true, out.Tags == nil)
+	})
+
+	t.Run("Round trip bracket notation", func(t *testing.T) {
+		type location struct {
+			Lat, Lng float64
+		}
+
+		type address struct {
+			HouseName string
+			Location  location
+			Labels    map[string]string `elem:"map"`
+		}
+
+		type test struct {
+			Name    string
+			Address address
+		}
+
+		in := test{
+			Name: "Dave",
+			Address: address{
+				HouseName: "Rose Cottage",
+				Location:  location{Lat: 51.5, Lng: -0.1},
+				Labels:    map[string]string{"gate": "blue"},
+			},
+		}
+
+		buf := new(bytes.Buffer)
+		enc := NewEncoder(buf, nil, nil)
+		enc.SetBracketNotation(true)
+
+		if err := enc.Encode(&in); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		for _, expected := range []string{
+			`name="Name"`,
+			`name="Address[HouseName]"`,
+			`name="Address[Location][Lat]"`,
+			`name="Address[Labels][gate]"`,
+		} {
+			if !strings.Contains(b, expected) {
+				t.Errorf("Expected %s in output, got: %s", expected, b)
+			}
+		}
+
+		form := url.Values{
+			"Name":                   {"Dave"},
+			"Address[HouseName]":     {"Rose Cottage"},
+			"Address[Location][Lat]": {"51.5"},
+			"Address[Location][Lng]": {"-0.1"},
+			"Address[Labels][gate]":  {"blue"},
+		}
+
+		var out test
+
+		dec := NewDecoder(form)
+		dec.SetBracketNotation(true)
+
+		if err := dec.Decode(&out); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, in.Name, out.Name)
+		assertEquals(t, in.Address.HouseName, out.Address.HouseName)
+		assertEquals(t, in.Address.Location, out.Address.Location)
+		assertEquals(t, 1, len(out.Address.Labels))
+		assertEquals(t, "blue", out.Address.Labels["gate"])
+	})
+
+	t.Run("Round trip with custom JSON functions", func(t *testing.T) {
+		type test struct {
+			Tags []string
+		}
+
+		in := test{Tags: []string{"<b>", "i"}}
+
+		buf := new(bytes.Buffer)
+		enc := NewEncoder(buf, nil, nil)
+		enc.SetJSONMarshaler(func(v interface{}) ([]byte, error) {
+			b := new(bytes.Buffer)
+
+			e := json.NewEncoder(b)
+			e.SetEscapeHTML(false)
+
+			if err := e.Encode(v); err != nil {
+				return nil, err
+			}
+
+			return bytes.TrimSpace(b.Bytes()), nil
+		})
+
+		if err := enc.Encode(&in); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if !strings.Contains(buf.String(), `<textarea name="Tags" id="Tags">[&#34;&lt;b&gt;&#34;,&#34;i&#34;]</textarea>`) {
+			t.Errorf("Expected compact, unescaped JSON, got: %s", buf.String())
+		}
+
+		calls := 0
+
+		var out test
+
+		dec := NewDecoder(url.Values{"Tags": {`["<b>","i"]`}})
+		dec.SetJSONUnmarshaler(func(data []byte, v interface{}) error {
+			calls++
+			return json.Unmarshal(data, v)
+		})
+
+		if err := dec.Decode(&out); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, 1, calls)
+		assertEquals(t, 2, len(out.Tags))
+		assertEquals(t, "<b>", out.Tags[0])
+		assertEquals(t, "i", out.Tags[1])
+	})
+
+	t.Run("Pointers to scalars distinguish absent from zero", func(t *testing.T) {
+		type test struct {
+			Age      *int
+			Nickname *string
+			Score    *float64
+			Active   *bool
+		}
+
+		var in test
+
+		buf := new(bytes.Buffer)
+
+		if err := NewEncoder(buf, nil, nil).Encode(&in); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, true, in.Age == nil)
+
+		if !strings.Contains(buf.String(), `<input type="number" name="Age" id="Age" value=""/>`) {
+			t.Errorf("Expected nil Age to be rendered as an empty input, got: %s", buf.String())
+		}
+
+		zero, nickname := 0, "Bob"
+		x := test{Nickname: &nickname}
+
+		dec := NewDecoder(url.Values{
+			"Age":   {"0"},
+			"Score": {""},
+		})
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if x.Age == nil || *x.Age != zero {
+			t.Errorf("Expected submitted Age to be 0, got: %v", x.Age)
+		}
+
+		if x.Nickname == nil || *x.Nickname != nickname {
+			t.Errorf("Expected Nickname to be unchanged, got: %v", x.Nickname)
+		}
+
+		assertEquals(t, true, x.Score == nil)
+		assertEquals(t, true, x.Active == nil)
+	})
+
+	t.Run("Pointers to unchecked checkboxes are cleared", func(t *testing.T) {
+		type test struct {
+			Confirm bool
+			Flag    *bool
+			Unset   *bool
+			Enabled *BoolNumber
+			Checked *BoolNumber
+		}
+
+		flag, enabled := true, BoolNumber(1)
+		x := test{Flag: &flag, Enabled: &enabled}
+
+		if err := NewDecoder(url.Values{"Confirm": {"on"}, "Checked": {"on"}}).Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if x.Flag == nil || *x.Flag {
+			t.Errorf("Expected Flag to be false, got: %v", x.Flag)
+		}
+
+		if x.Enabled == nil || *x.Enabled != 0 {
+			t.Errorf("Expected Enabled to be 0, got: %v", x.Enabled)
+		}
+
+		if x.Checked == nil || *x.Checked != 1 {
+			t.Errorf("Expected Checked to be 1, got: %v", x.Checked)
+		}
+
+		assertEquals(t, true, x.Unset == nil)
+		assertEquals(t, true, flag)
+	})
+
+	t.Run("Empty times with default now", func(t *testing.T) {
+		type test struct {
+			CreatedAt time.Time `default:"now" required:"true"`
+			DeletedAt time.Time
+		}
+
+		var x test
+
+		before := time.Now()
+
+		if err := NewDecoder(url.Values{"CreatedAt": {""}, "DeletedAt": {""}}).Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if x.CreatedAt.Before(before) || x.CreatedAt.After(time.Now()) {
+			t.Errorf("Expected CreatedAt to be close to now, got: %s", x.CreatedAt)
+		}
+
+		assertEquals(t, time.UTC, x.CreatedAt.Location())
+		assertEquals(t, true, x.DeletedAt.IsZero())
+	})
+
+	t.Run("Round trip repeatable groups", func(t *testing.T) {
+		type address struct {
+			Street string
+			City   string
+		}
+
+		type test struct {
+			Addresses []address `elem:"repeat"`
+		}
+
+		buf := new(bytes.Buffer)
+
+		if err := NewEncoder(buf, nil, nil).Encode(&test{Addresses: []address{{Street: "1 High Street", City: "London"}}}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		for _, expected := range []string{
+			`<fieldset><legend>Addresses</legend><div id="Addresses" data-repeat="Addresses">` +
+				`<input type="hidden" name="Addresses" value=""/><div data-repeat-item="0">`,
+			`<input type="text" name="Addresses[0].Street" id="Addresses[0].Street" value="1 High Street"/>`,
+			`<button type="button" data-repeat-remove="Addresses[0]">Remove</button></div>`,
+			`<template data-repeat-template="Addresses"><div data-repeat-item="__index__">`,
+			`<input type="text" name="Addresses[__index__].City" id="Addresses[__index__].City" value=""/>`,
+			`</template><button type="button" data-repeat-add="Addresses">Add</button></div></fieldset>`,
+		} {
+			if !strings.Contains(b, expected) {
+				t.Errorf("Expected %s in output, got: %s", expected, b)
+			}
+		}
+
+		// the client removed item 1 and added items 2 and 5.
+		form := url.Values{
+			"Addresses":           {""},
+			"Addresses[0].Street": {"1 High Street"},
+			"Addresses[0].City":   {"London"},
+			"Addresses[2].Street": {"2 Low Road"},
+			"Addresses[2].City":   {"Leeds"},
+			"Addresses[5].Street": {"3 Mill Lane"},
+			"Addresses[5].City":   {"York"},
+		}
+
+		var out test
+
+		if err := NewDecoder(form).Decode(&out); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, 3, len(out.Addresses))
+		assertEquals(t, address{Street: "1 High Street", City: "London"}, out.Addresses[0])
+		assertEquals(t, address{Street: "2 Low Road", City: "Leeds"}, out.Addresses[1])
+		assertEquals(t, address{Street: "3 Mill Lane", City: "York"}, out.Addresses[2])
+
+		// the client removed every item, so only the group's hidden input is submitted.
+		if err := NewDecoder(url.Values{"Addresses": {""}}).Decode(&out); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, 0, len(out.Addresses))
+	})
+
+	t.Run("Read only fmt.Stringer fields are left unchanged", func(t *testing.T) {
+		type test struct {
+			Duration time.Duration `readonly:"true"`
+		}
+
+		out := test{Duration: 90 * time.Minute}
+
+		if err := NewDecoder(url.Values{"Duration": {"1h30m0s"}}).Decode(&out); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, 90*time.Minute, out.Duration)
+	})
+
+	t.Run("Show conditions depending on sibling values", func(t *testing.T) {
+		type delivery struct {
+			Method       string
+			Instructions string `show:"courierOnly"`
+		}
+
+		type test struct {
+			Delivery delivery
+		}
+
+		courierOnly := func(field StructField) bool {
+			return field.Parent().FieldByName("Method").String() == "courier"
+		}
+
+		for _, method := range []string{"post", "courier"} {
+			buf := new(bytes.Buffer)
+			enc := NewEncoder(buf, nil, nil)
+			enc.AddShowCondition("courierOnly", courierOnly)
+
+			if err := enc.Encode(&test{Delivery: delivery{Method: method}}); err != nil {
+				t.Error(err)
+				return
+			}
+
+			b := buf.String()
+
+			if !strings.Contains(b, `name="Delivery.Method"`) {
+				t.Errorf("Expected the Delivery fieldset to be rendered for %s, got: %s", method, b)
+			}
+
+			assertEquals(t, method == "courier", strings.Contains(b, `name="Delivery.Instructions"`))
+
+			out := test{Delivery: delivery{Method: method}}
+
+			dec := NewDecoder(url.Values{"Delivery.Instructions": {"Leave with a neighbour"}})
+			dec.AddShowCondition("courierOnly", courierOnly)
+
+			if err := dec.Decode(&out); err != nil {
+				t.Error(err)
+				return
+			}
+
+			assertEquals(t, method == "courier", out.Delivery.Instructions != "")
+		}
+	})
+
+	t.Run("Round trip encoding.TextMarshaler", func(t *testing.T) {
+		type test struct {
+			ID      textUUID
+			Address net.IP
+			Gateway *net.IP
+		}
+
+		buf := new(bytes.Buffer)
+
+		in := test{
+			ID:      textUUID{0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8},
+			Address: net.ParseIP("192.168.0.1"),
+		}
+
+		if err := NewEncoder(buf, nil, nil).Encode(&in); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		for _, expected := range []string{
+			`<input type="text" name="ID" id="ID" value="6ba7b810-9dad-11d1-80b4-00c04fd430c8"/>`,
+			`<input type="text" name="Address" id="Address" value="192.168.0.1"/>`,
+			`<input type="text" name="Gateway" id="Gateway" value=""/>`,
+		} {
+			if !strings.Contains(b, expected) {
+				t.Errorf("Expected encoded form to contain %s, got: %s", expected, b)
+			}
+		}
+
+		var out test
+
+		if err := NewDecoder(url.Values{
+			"ID":      {"6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+			"Address": {"10.0.0.1"},
+			"Gateway": {"10.0.0.254"},
+		}).Decode(&out); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, in.ID, out.ID)
+		assertEquals(t, "10.0.0.1", out.Address.String())
+		assertEquals(t, "10.0.0.254", out.Gateway.String())
+
+		dec := NewDecoder(url.Values{"ID": {"not-a-uuid"}, "Address": {""}})
+		dec.SetInvalidInputAsValidationError(true)
+
+		err := dec.Decode(&out)
+
+		var validationErrors ValidationErrors
+
+		if !errors.As(err, &validationErrors) {
+			t.Errorf("Expected validation errors, got: %v", err)
+			return
+		}
+
+		assertEquals(t, 1, len(validationErrors["ID"]))
+		assertEquals(t, true, out.Address == nil)
+	})
+
+	t.Run("Round trip big.Rat", func(t *testing.T) {
+		type test struct {
+			Fraction *big.Rat
+			Decimal  big.Rat
+		}
+
+		buf := new(bytes.Buffer)
+
+		in := test{Fraction: big.NewRat(1, 3)}
+		in.Decimal.SetFloat64(0.25)
+
+		if err := NewEncoder(buf, nil, nil).Encode(&in); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		for _, expected := range []string{
+			`<input type="text" name="Fraction" id="Fraction" value="1/3"/>`,
+			`<input type="text" name="Decimal" id="Decimal" value="1/4"/>`,
+		} {
+			if !strings.Contains(b, expected) {
+				t.Errorf("Expected encoded form to contain %s, got: %s", expected, b)
+			}
+		}
+
+		var out test
+
+		if err := NewDecoder(url.Values{"Fraction": {"1/3"}, "Decimal": {"0.25"}}).Decode(&out); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, 0, out.Fraction.Cmp(big.NewRat(1, 3)))
+		assertEquals(t, 0, out.Decimal.Cmp(big.NewRat(1, 4)))
+
+		dec := NewDecoder(url.Values{"Fraction": {"one third"}, "Decimal": {"0.5"}})
+		dec.SetInvalidInputAsValidationError(true)
+
+		err := dec.Decode(&out)
+
+		var validationErrors ValidationErrors
+
+		if !errors.As(err, &validationErrors) {
+			t.Errorf("Expected ValidationErrors, got: %v", err)
+			return
+		}
+
+		if len(validationErrors["Fraction"]) != 1 {
+			t.Errorf("Expected one validation error for Fraction, got: %v", validationErrors)
+			return
+		}
+
+		assertEquals(t, "one third", validationErrors["Fraction"][0].Value)
+		assertEquals(t, 0, out.Fraction.Cmp(big.NewRat(1, 3)))
+		assertEquals(t, 0, out.Decimal.Cmp(big.NewRat(1, 2)))
+	})
+
+	t.Run("Absent custom decoder values", func(t *testing.T) {
+		type test struct {
+			FavouriteFoods FoodSelect
+			Enabled        BoolNumber
+			Notes          Raw
+		}
+
+		x := test{
+			FavouriteFoods: FoodSelect{"pizza"},
+			Enabled:        1,
+			Notes:          Raw("notes"),
+		}
+
+		if err := NewDecoder(url.Values{}).Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, 1, len(x.FavouriteFoods))
+		assertEquals(t, "pizza", x.FavouriteFoods[0])
+		assertEquals(t, BoolNumber(0), x.Enabled)
+		assertEquals(t, "notes", string(x.Notes))
+
+		v, err := BoolNumber(1).DecodeFormValue(url.Values{}, "formulate.test.Enabled", nil)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, BoolNumber(0), v.Interface())
+	})
+
+	t.Run("Case insensitive options are stored canonically", func(t *testing.T) {
+		type test struct {
+			Planet planet
+			Foods  FoodSelect
+			Other  planet
+		}
+
+		var x test
+
+		dec := NewDecoder(url.Values{
+			"Planet": {"eARTH"},
+			"Foods":  {"PIZZA", "beans"},
+			"Other":  {"Pluto"},
+		})
+		dec.SetCaseInsensitiveOptions(true)
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, planet("Earth"), x.Planet)
+		assertEquals(t, "pizza,beans", strings.Join(x.Foods, ","))
+		assertEquals(t, planet("Pluto"), x.Other)
+
+		x = test{}
+
+		if err := NewDecoder(url.Values{"Planet": {"eARTH"}}).Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, planet("eARTH"), x.Planet)
+	})
+
+	t.Run("Round trip anonymous structs", func(t *testing.T) {
+		type inner struct {
+			Street string
+			Deeper struct {
+				Code Month
+			}
+		}
+
+		// the type name of an anonymous struct contains the dots of its field types, e.g. "time.Time".
+		var in struct {
+			Name    string
+			Created time.Time `format:"date"`
+			Expires Week
+			Address inner
+		}
+
+		in.Name = "Jane"
+		in.Created = time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC)
+		in.Address.Street = "1 High Street"
+
+		buf := new(bytes.Buffer)
+
+		if err := NewEncoder(buf, nil, nil).Encode(&in); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		for _, expected := range []string{
+			`<input type="text" name="Name" id="Name" value="Jane"/>`,
+			`<input type="date" name="Created" id="Created" value="2020-01-02"/>`,
+			`name="Expires"`,
+			`<input type="text" name="Address.Street" id="Address.Street" value="1 High Street"/>`,
+			`name="Address.Deeper.Code"`,
+		} {
+			if !strings.Contains(b, expected) {
+				t.Errorf("Expected encoded form to contain %s, got: %s", expected, b)
+			}
+		}
+
+		out := in
+
+		out.Name = ""
+		out.Address.Street = ""
+
+		form := url.Values{
+			"Name":                {"John"},
+			"Created":             {"2021-03-04"},
+			"Expires":             {"2021-W10"},
+			"Address.Street":      {"2 Low Road"},
+			"Address.Deeper.Code": {"2021-05"},
+		}
+
+		if err := NewDecoder(form).Decode(&out); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, "John", out.Name)
+		assertEquals(t, true, out.Created.Equal(time.Date(2021, time.March, 4, 0, 0, 0, 0, time.UTC)))
+		assertEquals(t, true, out.Expires.Equal(time.Date(2021, time.March, 8, 0, 0, 0, 0, time.UTC)))
+		assertEquals(t, "2 Low Road", out.Address.Street)
+		assertEquals(t, true, out.Address.Deeper.Code.Equal(time.Date(2021, time.May, 1, 0, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("Round trip element name prefix", func(t *testing.T) {
+		type address struct {
+			Street   string
+			PostCode string
+		}
+
+		type test struct {
+			Name    string
+			Address address
+		}
+
+		buf := new(bytes.Buffer)
+		enc := NewEncoderWithOptions(buf, WithElementNamePrefix("billing-"))
+
+		if err := enc.Encode(&test{Name: "Jane", Address: address{Street: "1 High Street"}}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		for _, expected := range []string{
+			`<input type="text" name="billing-Name" id="billing-Name" value="Jane"/>`,
+			`<input type="text" name="billing-Address.Street" id="billing-Address.Street" value="1 High Street"/>`,
+		} {
+			if !strings.Contains(b, expected) {
+				t.Errorf("Expected encoded form to contain %s, got: %s", expected, b)
+			}
+		}
+
+		var out test
+
+		dec := NewDecoder(url.Values{
+			"billing-Name":             {"Jane"},
+			"billing-Address.Street":   {"1 High Street"},
+			"billing-Address.PostCode": {"AB1 2CD"},
+			"shipping-Name":            {"John"},
+			"Name":                     {"Unprefixed"},
+		})
+		dec.SetElementNamePrefix("billing-")
+
+		if err := dec.Decode(&out); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, test{Name: "Jane", Address: address{Street: "1 High Street", PostCode: "AB1 2CD"}}, out)
+	})
+
+	t.Run("Round trip full element names", func(t *testing.T) {
+		type location struct {
+			Lat float64
+		}
+
+		type address struct {
+			Street   string
+			Location location
+		}
 
-		defer func() {
-			if r := recover(); r == nil {
-				t.Errorf("Expected panic() on non-ptr type.")
-			}
-		}()
+		type test struct {
+			Name    string
+			Address address
+		}
 
-		_ = dec.Decode(out)
-	})
+		in := test{Name: "Jane", Address: address{Street: "1 High Street", Location: location{Lat: 51.5}}}
 
-	t.Run("Decode on non-struct type", func(t *testing.T) {
-		dec := NewDecoder(nil)
+		buf := new(bytes.Buffer)
+		enc := NewEncoderWithOptions(buf, WithFullElementNames(true))
 
-		var out int
+		if err := enc.Encode(&in); err != nil {
+			t.Error(err)
+			return
+		}
 
-		defer func() {
-			if r := recover(); r == nil {
-				t.Errorf("Expected panic() on non-struct type.")
+		b := buf.String()
+
+		for _, expected := range []string{
+			`<input type="text" name="formulate.test.Name" id="formulate.test.Name" value="Jane"/>`,
+			`<input type="text" name="formulate.test.Address.Street" id="formulate.test.Address.Street" value="1 High Street"/>`,
+			`<input type="number" name="formulate.test.Address.Location.Lat" id="formulate.test.Address.Location.Lat" value="51.5" step="any"/>`,
+		} {
+			if !strings.Contains(b, expected) {
+				t.Errorf("Expected encoded form to contain %s, got: %s", expected, b)
 			}
-		}()
+		}
 
-		_ = dec.Decode(&out)
-	})
+		form := make(url.Values)
 
-	t.Run("Custom decoder", func(t *testing.T) {
-		dec := NewDecoder(url.Values{"CustomValue": {"1", "7", "33"}})
+		for _, match := range regexp.MustCompile(`name="([^"]+)"[^>]* value="([^"]*)"`).FindAllStringSubmatch(b, -1) {
+			form.Add(match[1], match[2])
+		}
 
-		var out customDecoderTest
+		form.Add("Name", "Unqualified")
+
+		var out test
+
+		dec := NewDecoder(form)
+		dec.SetFullElementNames(true)
 
 		if err := dec.Decode(&out); err != nil {
 			t.Error(err)
+			return
 		}
 
-		if out[0] != 1 && out[1] != 7 && out[2] != 33 {
-			t.Fail()
-		}
+		assertEquals(t, in, out)
 	})
 
-	t.Run("Decode into interface{} as struct field", func(t *testing.T) {
+	t.Run("Unicode normalization and trimming", func(t *testing.T) {
 		type test struct {
-			Data interface{}
+			Name     string
+			Username string
+			Password Password
 		}
 
-		x := test{Data: "this is a string"}
+		// composes e + U+0301 COMBINING ACUTE ACCENT, like norm.NFC.
+		nfc := replacerNormalizer{strings.NewReplacer("e\u0301", "\u00e9")}
 
-		dec := NewDecoder(url.Values{"Data": {"new string"}})
+		var x test
+
+		dec := NewDecoder(url.Values{
+			"Name":     {"  Rene\u0301e\u200b  "},
+			"Username": {"\ufeffjose\u0301"},
+			"Password": {" pa\u200bss "},
+		})
+		dec.SetUnicodeNormalization(nfc)
+		dec.SetStripZeroWidthCharacters(true)
+		dec.SetTrimSpace(true)
 
 		if err := dec.Decode(&x); err != nil {
 			t.Error(err)
+			return
 		}
 
-		assertEquals(t, x.Data, "new string")
-	})
+		assertEquals(t, "Ren\u00e9e", x.Name)
+		assertEquals(t, "jos\u00e9", x.Username)
+		assertEquals(t, Password(" pa\u200bss "), x.Password)
 
-	t.Run("Decode without losing value in interface{}", func(t *testing.T) {
-		type testData struct {
-			Value string
+		x = test{}
+
+		if err := NewDecoder(url.Values{"Name": {" Rene\u0301e "}}).Decode(&x); err != nil {
+			t.Error(err)
+			return
 		}
 
+		assertEquals(t, " Rene\u0301e ", x.Name)
+	})
+
+	t.Run("Round trip flags", func(t *testing.T) {
 		type test struct {
-			Data interface{}
+			Permissions permission
 		}
 
-		x := test{Data: &testData{Value: "this is a string"}}
-
-		dec := NewDecoder(url.Values{})
+		buf := new(bytes.Buffer)
 
-		if err := dec.Decode(&x); err != nil {
+		if err := NewEncoder(buf, nil, nil).Encode(&test{Permissions: permissionRead | permissionExecute}); err != nil {
 			t.Error(err)
 			return
 		}
 
-		if x.Data == nil {
-			t.Fail()
+		b := buf.String()
+
+		for _, expected := range []string{
+			`<input type="hidden" name="Permissions" value=""/><select name="Permissions" id="Permissions" multiple="">`,
+			`<option value="1" selected="">Read</option>`,
+			`<option value="2">Write</option>`,
+			`<option value="4" selected="">Execute</option>`,
+		} {
+			if !strings.Contains(b, expected) {
+				t.Errorf("Expected %s in output, got: %s", expected, b)
+			}
+		}
+
+		form := url.Values{
+			"Permissions": {"", "2", "4"},
+		}
+
+		var x test
+
+		if err := NewDecoder(form).Decode(&x); err != nil {
+			t.Error(err)
 			return
 		}
 
-		v, ok := x.Data.(*testData)
+		assertEquals(t, permissionWrite|permissionExecute, x.Permissions)
 
-		if !ok || v == nil {
-			t.Fail()
+		// only the hidden value is submitted when no flags are selected.
+		if err := NewDecoder(url.Values{"Permissions": {""}}).Decode(&x); err != nil {
+			t.Error(err)
 			return
 		}
 
-		assertEquals(t, v.Value, "this is a string")
+		assertEquals(t, permission(0), x.Permissions)
+
+		form = url.Values{
+			"Permissions": {"2", "8"},
+		}
+
+		if err := NewDecoder(form).Decode(&x); !errors.Is(err, ErrInvalidFlag) {
+			t.Errorf("Expected ErrInvalidFlag, got: %v", err)
+		}
 	})
 
-	t.Run("Decode where empty value overwrites already set value in struct", func(t *testing.T) {
-		type testData struct {
-			Value string
+	t.Run("Decode time in location", func(t *testing.T) {
+		london, err := time.LoadLocation("Europe/London")
+
+		if err != nil {
+			t.Skip(err)
 		}
 
-		x := testData{Value: "this is a string"}
+		type test struct {
+			Meeting time.Time
+			Flight  time.Time `tz:"America/New_York"`
+		}
 
-		dec := NewDecoder(url.Values{"Value": []string{""}})
+		var x test
+
+		dec := NewDecoder(url.Values{"Meeting": {"2020-05-28T15:00"}, "Flight": {"2020-05-28T15:00"}})
+		dec.SetTimeLocation(london)
 
 		if err := dec.Decode(&x); err != nil {
 			t.Error(err)
 			return
 		}
 
-		if x.Value != "" {
+		assertEquals(t, x.Meeting.Location().String(), "Europe/London")
+		assertEquals(t, x.Meeting.Hour(), 15)
+		assertEquals(t, x.Meeting.UTC().Hour(), 14)
+		assertEquals(t, x.Flight.Location().String(), "America/New_York")
+		assertEquals(t, x.Flight.Hour(), 15)
+	})
+
+	t.Run("Struct aware validator compares decoded fields", func(t *testing.T) {
+		type test struct {
+			Password        Password
+			ConfirmPassword Password `validators:"confirmPassword"`
+		}
+
+		var x test
+
+		store := NewMemoryValidationStore()
+		dec := NewDecoder(url.Values{"Password": {"hunter2"}, "ConfirmPassword": {"hunter3"}})
+		dec.SetValidationStore(store)
+		dec.AddValidators(&confirmPasswordValidator{})
+
+		if err := dec.Decode(&x); !errors.Is(err, ErrFormFailedValidation) {
+			t.Errorf("Expected ErrFormFailedValidation, got: %v", err)
+			return
+		}
+
+		validationErrors, err := store.GetValidationErrors("ConfirmPassword")
+
+		if err != nil || len(validationErrors) != 1 {
 			t.Fail()
+		}
+
+		x = test{}
+		dec = NewDecoder(url.Values{"Password": {"hunter2"}, "ConfirmPassword": {"hunter2"}})
+		dec.AddValidators(&confirmPasswordValidator{})
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
 			return
 		}
+
+		assertEquals(t, x.ConfirmPassword, Password("hunter2"))
 	})
 }
 
@@ -232,6 +1970,22 @@ func (c customDecoderTest) DecodeFormValue(form url.Values, name string, values
 	return reflect.ValueOf(out), nil
 }
 
+// upperCaseDecoder decodes its form value in upper case.
+type upperCaseDecoder string
+
+func (u upperCaseDecoder) DecodeFormValue(form url.Values, name string, _ []string) (reflect.Value, error) {
+	value, _ := PopFormValue(form, FormElementName(name))
+
+	return reflect.ValueOf(upperCaseDecoder(strings.ToUpper(value))), nil
+}
+
+// failingDecoder always fails to decode.
+type failingDecoder string
+
+func (f failingDecoder) DecodeFormValue(form url.Values, name string, _ []string) (reflect.Value, error) {
+	return reflect.Value{}, errors.New("invalid value")
+}
+
 type emptySlice []string
 
 func (e emptySlice) DecodeFormValue(form url.Values, name string, values []string) (reflect.Value, error) {
@@ -305,6 +2059,262 @@ func (c countryCodeValidator) TagName() string {
 	return "countryCode"
 }
 
+type contextCountryCodeValidator struct {
+	countryCodeValidator
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (c *contextCountryCodeValidator) ValidateContext(ctx context.Context, value interface{}) (ok bool, message string) {
+	c.ctx = ctx
+	c.cancel()
+
+	return c.Validate(value)
+}
+
+func (c *contextCountryCodeValidator) TagName() string {
+	return "contextCountryCode"
+}
+
+type confirmPasswordValidator struct {
+	v reflect.Value
+}
+
+func (c *confirmPasswordValidator) Validate(value interface{}) (ok bool, message string) {
+	if c.v.FieldByName("Password").String() != value.(string) {
+		return false, "Passwords do not match"
+	}
+
+	return true, ""
+}
+
+func (c *confirmPasswordValidator) TagName() string {
+	return "confirmPassword"
+}
+
+func (c *confirmPasswordValidator) SetStruct(v reflect.Value) {
+	c.v = v
+}
+
+func TestHTTPDecoder_RequiredWhen(t *testing.T) {
+	type test struct {
+		ConfirmedEmail bool
+		Email          string `requiredwhen:" ConfirmedEmail = on "`
+	}
+
+	t.Run("Field is required when the condition is met", func(t *testing.T) {
+		dec := NewDecoder(url.Values{
+			"ConfirmedEmail": {"on"},
+			"Email":          {""},
+		})
+
+		var out test
+
+		err := dec.Decode(&out)
+
+		var validationErrors ValidationErrors
+
+		if !errors.As(err, &validationErrors) {
+			t.Errorf("Expected ValidationErrors, got: %v", err)
+			return
+		}
+
+		if len(validationErrors["Email"]) != 1 {
+			t.Errorf("Expected one validation error for Email, got: %v", validationErrors)
+			return
+		}
+
+		assertEquals(t, RequiredIfValidationMessage, validationErrors["Email"][0].Error)
+	})
+
+	t.Run("Omitted field is required when the condition is met", func(t *testing.T) {
+		dec := NewDecoder(url.Values{
+			"ConfirmedEmail": {"on"},
+		})
+
+		var out test
+
+		err := dec.Decode(&out)
+
+		var validationErrors ValidationErrors
+
+		if !errors.As(err, &validationErrors) {
+			t.Errorf("Expected ValidationErrors, got: %v", err)
+			return
+		}
+
+		if len(validationErrors["Email"]) != 1 {
+			t.Errorf("Expected one validation error for Email, got: %v", validationErrors)
+			return
+		}
+
+		assertEquals(t, RequiredIfValidationMessage, validationErrors["Email"][0].Error)
+	})
+
+	t.Run("Omitted fields are only validated once", func(t *testing.T) {
+		type test struct {
+			Confirm bool
+			When    time.Time `requiredwhen:"Confirm=on"`
+			Amount  big.Rat   `requiredwhen:"Confirm=on"`
+		}
+
+		var out test
+
+		err := NewDecoder(url.Values{"Confirm": {"on"}}).Decode(&out)
+
+		var validationErrors ValidationErrors
+
+		if !errors.As(err, &validationErrors) {
+			t.Errorf("Expected ValidationErrors, got: %v", err)
+			return
+		}
+
+		assertEquals(t, 1, len(validationErrors["When"]))
+		assertEquals(t, 1, len(validationErrors["Amount"]))
+	})
+
+	t.Run("Field is set when the condition is met", func(t *testing.T) {
+		dec := NewDecoder(url.Values{
+			"ConfirmedEmail": {"on"},
+			"Email":          {"user@example.com"},
+		})
+
+		var out test
+
+		if err := dec.Decode(&out); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, "user@example.com", out.Email)
+	})
+
+	t.Run("Field is optional when the condition is not met", func(t *testing.T) {
+		dec := NewDecoder(url.Values{
+			"Email": {""},
+		})
+
+		var out test
+
+		if err := dec.Decode(&out); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestHTTPDecoder_SetHoneypot(t *testing.T) {
+	type test struct {
+		Name    string
+		Website string
+	}
+
+	t.Run("Empty honeypot passes", func(t *testing.T) {
+		dec := NewDecoder(url.Values{
+			"Name":    {"Dave"},
+			"Website": {""},
+		})
+		dec.SetHoneypot("Website")
+
+		x := test{Website: "https://example.com"}
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, "Dave", x.Name)
+		assertEquals(t, "https://example.com", x.Website)
+	})
+
+	t.Run("Filled honeypot fails", func(t *testing.T) {
+		dec := NewDecoder(url.Values{
+			"Name":    {"Spammer"},
+			"Website": {"https://spam.example.com"},
+		})
+		dec.SetHoneypot("Website")
+
+		var x test
+
+		if err := dec.Decode(&x); !errors.Is(err, ErrHoneypotFilled) {
+			t.Errorf("Expected ErrHoneypotFilled, got: %v", err)
+		}
+
+		assertEquals(t, "", x.Name)
+	})
+}
+
+// money is an amount in cents, which is submitted in dollars.
+type money int64
+
+func parseMoney(values []string) (reflect.Value, error) {
+	f, err := strconv.ParseFloat(values[0], 64)
+
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	return reflect.ValueOf(money(f * 100)), nil
+}
+
+func TestHTTPDecoder_RegisterParser(t *testing.T) {
+	type invoice struct {
+		Total    money
+		Discount money
+		Quantity int
+	}
+
+	t.Run("Registered type is decoded with parser", func(t *testing.T) {
+		form := url.Values{
+			"Total":    {"12.50"},
+			"Quantity": {"3"},
+		}
+
+		var out invoice
+		out.Discount = 100
+
+		dec := NewDecoder(form)
+		dec.RegisterParser(reflect.TypeOf(money(0)), parseMoney)
+
+		if err := dec.Decode(&out); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, money(1250), out.Total)
+		assertEquals(t, money(100), out.Discount)
+		assertEquals(t, 3, out.Quantity)
+	})
+
+	t.Run("Parser errors are returned", func(t *testing.T) {
+		form := url.Values{
+			"Total": {"twelve"},
+		}
+
+		dec := NewDecoder(form)
+		dec.RegisterParser(reflect.TypeOf(money(0)), parseMoney)
+
+		if err := dec.Decode(&invoice{}); err == nil {
+			t.Error("Expected parser error, got nil")
+		}
+	})
+
+	t.Run("Parsed value of the wrong type", func(t *testing.T) {
+		form := url.Values{
+			"Total": {"12.50"},
+		}
+
+		dec := NewDecoder(form)
+		dec.RegisterParser(reflect.TypeOf(money(0)), func(values []string) (reflect.Value, error) {
+			return reflect.ValueOf(values[0]), nil
+		})
+
+		if err := dec.Decode(&invoice{}); !errors.Is(err, ErrInvalidParsedValue) {
+			t.Errorf("Expected ErrInvalidParsedValue, got: %v", err)
+		}
+	})
+}
+
 func TestHTTPDecoder_SetValidationStore(t *testing.T) {
 	t.Run("Valid store", func(t *testing.T) {
 		dec := NewDecoder(nil)
@@ -324,3 +2334,50 @@ func TestHTTPDecoder_SetValidationStore(t *testing.T) {
 		assertEquals(t, dec.validationStore, current)
 	})
 }
+
+// replacerNormalizer is a UnicodeNormalizer which replaces strings using a strings.Replacer.
+type replacerNormalizer struct {
+	*strings.Replacer
+}
+
+func (r replacerNormalizer) String(s string) string {
+	return r.Replace(s)
+}
+
+// textUUID is a UUID which implements encoding.TextMarshaler and encoding.TextUnmarshaler in the same way as
+// github.com/google/uuid.UUID.
+type textUUID [16]byte
+
+func (u textUUID) MarshalText() ([]byte, error) {
+	h := hex.EncodeToString(u[:])
+
+	return []byte(h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]), nil
+}
+
+func (u *textUUID) UnmarshalText(text []byte) error {
+	b, err := hex.DecodeString(strings.Replace(string(text), "-", "", -1))
+
+	if err != nil || len(b) != len(u) || len(text) != 36 {
+		return fmt.Errorf("invalid UUID: %q", text)
+	}
+
+	copy(u[:], b)
+
+	return nil
+}
+
+type permission uint8
+
+const (
+	permissionRead permission = 1 << iota
+	permissionWrite
+	permissionExecute
+)
+
+func (permission) FlagOptions() []Flag {
+	return []Flag{
+		{Value: uint64(permissionRead), Label: "Read"},
+		{Value: uint64(permissionWrite), Label: "Write"},
+		{Value: uint64(permissionExecute), Label: "Execute"},
+	}
+}
@@ -38,6 +38,68 @@ type Decorator interface {
 	ValidationText(n *html.Node, field StructField)
 }
 
+// RequiredProgressDecorator is an optional extension to Decorator. If the Decorator passed to the HTMLEncoder
+// implements RequiredProgressDecorator, RequiredProgress is called to decorate the summary of remaining required
+// fields. See HTMLEncoder.SetRequiredProgress.
+type RequiredProgressDecorator interface {
+	// RequiredProgress decorates the summary element. remaining is the number of required fields which are empty,
+	// and total is the number of required fields in the form.
+	RequiredProgress(n *html.Node, remaining, total int)
+}
+
+// ResetControlDecorator is an optional extension to Decorator. If the Decorator passed to the HTMLEncoder
+// implements ResetControlDecorator, ResetControl is called to decorate the reset control of each field.
+// See HTMLEncoder.SetFieldResetControls.
+type ResetControlDecorator interface {
+	// ResetControl decorates the <button type="button"> which restores the default value of a field.
+	ResetControl(n *html.Node, field StructField)
+}
+
+// MeterDecorator is an optional extension to Decorator. If the Decorator passed to the HTMLEncoder implements
+// MeterDecorator, it is called to decorate the elements rendered for Meter and Progress fields.
+type MeterDecorator interface {
+	// Meter decorates the <meter> element of a Meter field.
+	Meter(n *html.Node, field StructField)
+	// Progress decorates the <progress> element of a Progress field.
+	Progress(n *html.Node, field StructField)
+}
+
+// LabelErrorDecorator is an optional extension to Decorator. If the Decorator passed to the HTMLEncoder implements
+// LabelErrorDecorator, LabelError is called to decorate validation errors which are rendered within a <label>.
+// See HTMLEncoder.SetErrorInLabel.
+type LabelErrorDecorator interface {
+	// LabelError decorates the <span> containing the validation error.
+	LabelError(n *html.Node, field StructField)
+}
+
+// RatingDecorator is an optional extension to Decorator. If the Decorator passed to the HTMLEncoder implements
+// RatingDecorator, Rating is called to decorate the radio inputs of Rating fields, e.g. to style them as stars.
+type RatingDecorator interface {
+	// Rating decorates the <div data-rating> which contains a <label> and <input type="radio"> for each star.
+	Rating(n *html.Node, field StructField)
+}
+
+// FieldGroupDecorator is an optional extension to Decorator. If the Decorator passed to the HTMLEncoder implements
+// FieldGroupDecorator, FieldGroup is called to decorate the wrapper of each group of fields which share a group tag.
+type FieldGroupDecorator interface {
+	// FieldGroup decorates the <div> which wraps the rows of the fields in the named group.
+	FieldGroup(n *html.Node, group string)
+}
+
+// HintDecorator is an optional extension to Decorator. If the Decorator passed to the HTMLEncoder implements
+// HintDecorator, Hint is called to decorate the hint of each field which has a "hint" struct tag.
+type HintDecorator interface {
+	// Hint decorates the <small> element which is displayed within the <label> of a form element.
+	Hint(n *html.Node, field StructField)
+}
+
+// SubmitButtonDecorator is an optional extension to Decorator. If the Decorator passed to the HTMLEncoder
+// implements SubmitButtonDecorator, SubmitButton is called to decorate the submit button. See HTMLEncoder.SetSubmitButton.
+type SubmitButtonDecorator interface {
+	// SubmitButton decorates the <button type="submit"> rendered inside the <form>.
+	SubmitButton(n *html.Node)
+}
+
 type nilDecorator struct{}
 
 func (d nilDecorator) RootNode(n *html.Node) {}
@@ -10,6 +10,7 @@ import (
 type BootstrapDecorator struct{}
 
 var _ formulate.Decorator = &BootstrapDecorator{}
+var _ formulate.RequiredProgressDecorator = &BootstrapDecorator{}
 
 func (b BootstrapDecorator) FieldWrapper(n *html.Node, field formulate.StructField) {
 	b.col8(n)
@@ -20,6 +21,10 @@ func (b BootstrapDecorator) HelpText(n *html.Node, field formulate.StructField)
 	formulate.AppendClass(n, "small mt-1")
 }
 
+func (b BootstrapDecorator) Hint(n *html.Node, field formulate.StructField) {
+	formulate.AppendClass(n, "text-muted", "ml-1")
+}
+
 func (b BootstrapDecorator) RootNode(n *html.Node) {
 
 }
@@ -87,6 +92,14 @@ func (b BootstrapDecorator) ValidationText(n *html.Node, field formulate.StructF
 	}
 }
 
+func (b BootstrapDecorator) SubmitButton(n *html.Node) {
+	formulate.AppendClass(n, "btn", "btn-primary")
+}
+
+func (b BootstrapDecorator) RequiredProgress(n *html.Node, remaining, total int) {
+	formulate.AppendClass(n, "small", "text-muted", "mb-3")
+}
+
 func (b BootstrapDecorator) validation(n *html.Node, field formulate.StructField) {
 	if len(field.ValidationErrors) == 0 {
 		return
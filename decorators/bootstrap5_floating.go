@@ -0,0 +1,166 @@
+package decorators
+
+import (
+	"golang.org/x/net/html"
+
+	"github.com/cj123/formulate"
+)
+
+// BootstrapFloatingDecorator implements a form layout using Bootstrap 5 floating labels.
+//
+// Floating labels require the <label> to be placed after the input, inside a "form-floating" wrapper,
+// and the input must have a placeholder. formulate builds the label before the input, so the Row
+// decorator moves the label into the field wrapper, directly after the input. Placeholders are set to
+// the field name if the field does not specify one. Checkboxes are rendered using "form-check", and any
+// other elements (e.g. radio buttons) are rendered with the label above the input.
+type BootstrapFloatingDecorator struct{}
+
+var _ formulate.Decorator = &BootstrapFloatingDecorator{}
+var _ formulate.RequiredProgressDecorator = &BootstrapFloatingDecorator{}
+
+func (b BootstrapFloatingDecorator) RootNode(n *html.Node) {
+
+}
+
+func (b BootstrapFloatingDecorator) Fieldset(n *html.Node, field formulate.StructField) {
+
+}
+
+func (b BootstrapFloatingDecorator) Row(n *html.Node, field formulate.StructField) {
+	formulate.AppendClass(n, "mb-3")
+
+	label := n.FirstChild
+
+	if label == nil || label.Data != "label" || label.NextSibling == nil {
+		return
+	}
+
+	wrapper := label.NextSibling
+	input := wrapper.FirstChild
+
+	if input == nil {
+		return
+	}
+
+	switch {
+	case b.floats(input):
+		formulate.AppendClass(wrapper, "form-floating")
+	case b.isCheckbox(input):
+		formulate.AppendClass(wrapper, "form-check")
+		formulate.AppendClass(label, "form-check-label")
+	default:
+		formulate.AppendClass(label, "form-label")
+		return
+	}
+
+	n.RemoveChild(label)
+	wrapper.InsertBefore(label, input.NextSibling)
+}
+
+func (b BootstrapFloatingDecorator) FieldWrapper(n *html.Node, field formulate.StructField) {
+
+}
+
+func (b BootstrapFloatingDecorator) Label(n *html.Node, field formulate.StructField) {
+
+}
+
+func (b BootstrapFloatingDecorator) HelpText(n *html.Node, field formulate.StructField) {
+	formulate.AppendClass(n, "form-text")
+}
+
+func (b BootstrapFloatingDecorator) Hint(n *html.Node, field formulate.StructField) {
+	formulate.AppendClass(n, "text-muted", "ms-1")
+}
+
+func (b BootstrapFloatingDecorator) TextField(n *html.Node, field formulate.StructField) {
+	b.formControl(n, field)
+	b.validation(n, field)
+}
+
+func (b BootstrapFloatingDecorator) NumberField(n *html.Node, field formulate.StructField) {
+	b.formControl(n, field)
+	b.validation(n, field)
+}
+
+func (b BootstrapFloatingDecorator) CheckboxField(n *html.Node, field formulate.StructField) {
+	formulate.AppendClass(n, "form-check-input")
+	b.validation(n, field)
+}
+
+func (b BootstrapFloatingDecorator) TextareaField(n *html.Node, field formulate.StructField) {
+	b.formControl(n, field)
+	b.validation(n, field)
+}
+
+func (b BootstrapFloatingDecorator) TimeField(n *html.Node, field formulate.StructField) {
+	b.formControl(n, field)
+	b.validation(n, field)
+}
+
+func (b BootstrapFloatingDecorator) SelectField(n *html.Node, field formulate.StructField) {
+	formulate.AppendClass(n, "form-select")
+	b.validation(n, field)
+}
+
+func (b BootstrapFloatingDecorator) RadioButton(n *html.Node, field formulate.StructField) {
+	formulate.AppendClass(n, "form-check-input")
+	b.validation(n, field)
+}
+
+func (b BootstrapFloatingDecorator) ValidationText(n *html.Node, field formulate.StructField) {
+	if len(field.ValidationErrors) > 0 {
+		formulate.AppendClass(n, "invalid-feedback")
+	}
+}
+
+func (b BootstrapFloatingDecorator) SubmitButton(n *html.Node) {
+	formulate.AppendClass(n, "btn", "btn-primary")
+}
+
+func (b BootstrapFloatingDecorator) RequiredProgress(n *html.Node, remaining, total int) {
+	formulate.AppendClass(n, "form-text", "mb-3")
+}
+
+// formControl adds the form-control class, and a placeholder (which floating labels require) if there is not one already.
+func (b BootstrapFloatingDecorator) formControl(n *html.Node, field formulate.StructField) {
+	formulate.AppendClass(n, "form-control")
+
+	if !formulate.HasAttribute(n, "placeholder") {
+		n.Attr = append(n.Attr, html.Attribute{Key: "placeholder", Val: field.GetName()})
+	}
+}
+
+func (b BootstrapFloatingDecorator) validation(n *html.Node, field formulate.StructField) {
+	if len(field.ValidationErrors) == 0 {
+		return
+	}
+
+	formulate.AppendClass(n, "is-invalid")
+}
+
+// floats determines if a floating label can be used for n.
+func (b BootstrapFloatingDecorator) floats(n *html.Node) bool {
+	switch n.Data {
+	case "textarea", "select":
+		return true
+	case "input":
+		return !b.isCheckbox(n) && attributeValue(n, "type") != "radio"
+	default:
+		return false
+	}
+}
+
+func (b BootstrapFloatingDecorator) isCheckbox(n *html.Node) bool {
+	return n.Data == "input" && attributeValue(n, "type") == "checkbox"
+}
+
+func attributeValue(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+
+	return ""
+}
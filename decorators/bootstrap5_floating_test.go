@@ -0,0 +1,34 @@
+package decorators
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cj123/formulate"
+)
+
+func TestBootstrapFloatingDecorator(t *testing.T) {
+	type test struct {
+		Name  string
+		Agree bool
+	}
+
+	buf := new(bytes.Buffer)
+	enc := formulate.NewEncoder(buf, nil, BootstrapFloatingDecorator{})
+
+	if err := enc.Encode(&test{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	if !strings.Contains(b, `<div class="mb-3"><div class="form-floating"><input type="text" name="Name" id="Name" value="" class="form-control" placeholder="Name"/><label for="Name">Name</label>`) {
+		t.Errorf("Expected floating label structure for text input, got: %s", b)
+	}
+
+	if !strings.Contains(b, `<div class="mb-3"><div class="form-check"><input type="checkbox" name="Agree" id="Agree" class="form-check-input"/><label for="Agree" class="form-check-label">Agree</label>`) {
+		t.Errorf("Expected form-check structure for checkbox, got: %s", b)
+	}
+}
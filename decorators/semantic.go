@@ -0,0 +1,159 @@
+package decorators
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/cj123/formulate"
+)
+
+// SemanticDecorator implements a form layout using plain, accessible HTML with no CSS framework classes, for
+// styling with element and attribute selectors (e.g. [aria-invalid="true"]).
+//
+// formulate already renders nested structs as a <fieldset> with a <legend>, and wires inputs to their help and
+// validation text with aria-describedby. SemanticDecorator builds on this: the rows of radio buttons and checkbox
+// groups are rendered as a <fieldset> named by a <legend> (as a <label> can only name a single input) which is
+// described by the help and validation text, validation errors are announced with role="alert", and the summary
+// of remaining required fields is a role="status" live region. No other markup is changed.
+type SemanticDecorator struct{}
+
+var _ formulate.Decorator = &SemanticDecorator{}
+var _ formulate.RequiredProgressDecorator = &SemanticDecorator{}
+
+func (s SemanticDecorator) RootNode(n *html.Node) {
+
+}
+
+func (s SemanticDecorator) Fieldset(n *html.Node, field formulate.StructField) {
+
+}
+
+func (s SemanticDecorator) Row(n *html.Node, field formulate.StructField) {
+	label := n.FirstChild
+
+	if label == nil || label.Data != "label" || label.NextSibling == nil {
+		return
+	}
+
+	wrapper := label.NextSibling
+
+	if wrapper.FirstChild == nil || !s.isInputGroup(wrapper.FirstChild) {
+		return
+	}
+
+	// a group of inputs is named by the <legend> of a <fieldset>, rather than a <label>.
+	n.Data = "fieldset"
+	label.Data = "legend"
+	removeAttribute(label, "for")
+
+	var describedBy []string
+
+	for c := wrapper.FirstChild; c != nil; c = c.NextSibling {
+		id := attributeValue(c, "id")
+
+		switch {
+		case id == "":
+		case id == field.ValidationTextID && len(field.ValidationErrors) > 0, id == field.HelpTextID:
+			describedBy = append(describedBy, id)
+		}
+	}
+
+	if len(describedBy) > 0 {
+		n.Attr = append(n.Attr, html.Attribute{Key: "aria-describedby", Val: strings.Join(describedBy, " ")})
+
+		// the group is described by the <fieldset>, so its inputs are not described again.
+		for c := wrapper.FirstChild.FirstChild; c != nil; c = c.NextSibling {
+			if c.Data == "input" {
+				removeAttribute(c, "aria-describedby")
+			}
+		}
+	}
+}
+
+func (s SemanticDecorator) FieldWrapper(n *html.Node, field formulate.StructField) {
+
+}
+
+func (s SemanticDecorator) Label(n *html.Node, field formulate.StructField) {
+
+}
+
+func (s SemanticDecorator) HelpText(n *html.Node, field formulate.StructField) {
+
+}
+
+func (s SemanticDecorator) Hint(n *html.Node, field formulate.StructField) {
+
+}
+
+func (s SemanticDecorator) TextField(n *html.Node, field formulate.StructField) {
+
+}
+
+func (s SemanticDecorator) NumberField(n *html.Node, field formulate.StructField) {
+
+}
+
+func (s SemanticDecorator) CheckboxField(n *html.Node, field formulate.StructField) {
+
+}
+
+func (s SemanticDecorator) TextareaField(n *html.Node, field formulate.StructField) {
+
+}
+
+func (s SemanticDecorator) TimeField(n *html.Node, field formulate.StructField) {
+
+}
+
+func (s SemanticDecorator) SelectField(n *html.Node, field formulate.StructField) {
+
+}
+
+func (s SemanticDecorator) RadioButton(n *html.Node, field formulate.StructField) {
+
+}
+
+func (s SemanticDecorator) ValidationText(n *html.Node, field formulate.StructField) {
+	if len(field.ValidationErrors) > 0 {
+		n.Attr = append(n.Attr, html.Attribute{Key: "role", Val: "alert"})
+	}
+}
+
+func (s SemanticDecorator) SubmitButton(n *html.Node) {
+
+}
+
+func (s SemanticDecorator) RequiredProgress(n *html.Node, remaining, total int) {
+	n.Attr = append(n.Attr, html.Attribute{Key: "role", Val: "status"}, html.Attribute{Key: "aria-live", Val: "polite"})
+}
+
+// isInputGroup determines if n contains a group of radio buttons or checkboxes.
+func (s SemanticDecorator) isInputGroup(n *html.Node) bool {
+	if n.Data != "div" {
+		return false
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Data == "input" {
+			if typ := attributeValue(c, "type"); typ == "radio" || typ == "checkbox" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func removeAttribute(n *html.Node, key string) {
+	attrs := n.Attr[:0]
+
+	for _, attr := range n.Attr {
+		if attr.Key != key {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	n.Attr = attrs
+}
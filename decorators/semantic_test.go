@@ -0,0 +1,50 @@
+package decorators
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cj123/formulate"
+)
+
+func TestSemanticDecorator(t *testing.T) {
+	type test struct {
+		Name   string           `help:"Your full name"`
+		Rating formulate.Rating `help:"How was your stay?" max:"2"`
+	}
+
+	store := formulate.NewMemoryValidationStore()
+
+	if err := store.AddValidationError("Rating", formulate.ValidationError{Error: "Please rate your stay"}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	enc := formulate.NewEncoder(buf, nil, SemanticDecorator{})
+	enc.SetValidationStore(store)
+
+	if err := enc.Encode(&test{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	for _, expected := range []string{
+		`<div><label for="Name">Name</label><div><input type="text" name="Name" id="Name" value="" aria-describedby="Name-help"/>` +
+			`<div id="Name-help">Your full name</div></div></div>`,
+		`<fieldset aria-describedby="Rating-errors Rating-help"><legend id="Rating-label">Rating</legend>`,
+		`<input type="radio" value="1" id="Rating0" name="Rating" data-rating-value="1" aria-invalid="true"/>`,
+		`<div id="Rating-errors" role="alert">Please rate your stay</div><div id="Rating-help">How was your stay?</div></div></fieldset>`,
+	} {
+		if !strings.Contains(b, expected) {
+			t.Errorf("Expected %s in output, got: %s", expected, b)
+		}
+	}
+
+	if strings.Contains(b, "class=") {
+		t.Errorf("Expected no classes, got: %s", b)
+	}
+}
@@ -1,2 +1,9 @@
 // Package formulate is a set of tools for building HTML forms from structs, and parsing HTTP form values back into structs.
+//
+// The HTML built by formulate does not depend on JavaScript. Every built-in element is a standard form control
+// which is submitted and decoded without scripts, so forms work in browsers with scripts disabled. Some features
+// add data attributes which client side scripts may use to enhance the form, but which are also enforced by the
+// HTTPDecoder: fields with a requiredwhen tag are validated by a RequiredIfValidator whether or not a script
+// implements the condition in the browser. Computed fields are rendered as <output> elements containing the value
+// computed by the server, so they only change when the form is re-rendered.
 package formulate
@@ -42,8 +42,8 @@ func ExampleNewEncoder() {
 	//         House Name
 	//       </label>
 	//       <div>
-	//         <input type="text" name="HouseName" id="HouseName" value=""/>
-	//         <div>
+	//         <input type="text" name="HouseName" id="HouseName" value="" aria-describedby="HouseName-help"/>
+	//         <div id="HouseName-help">
 	//           You can leave this blank.
 	//         </div>
 	//       </div>
@@ -212,8 +212,8 @@ func ExampleFormulate() {
 	//         House Name
 	//       </label>
 	//       <div>
-	//         <input type="text" name="HouseName" id="HouseName" value=""/>
-	//         <div>
+	//         <input type="text" name="HouseName" id="HouseName" value="" aria-describedby="HouseName-help"/>
+	//         <div id="HouseName-help">
 	//           You can leave this blank.
 	//         </div>
 	//       </div>
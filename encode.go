@@ -2,12 +2,17 @@ package formulate
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html/template"
 	"io"
+	"math/big"
 	"net/http"
+	"path"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -20,6 +25,7 @@ import (
 // HTMLEncoder is used to generate an HTML form from a given struct.
 type HTMLEncoder struct {
 	ShowConditions
+	RequiredConditions
 
 	n *html.Node
 	w io.Writer
@@ -28,8 +34,47 @@ type HTMLEncoder struct {
 	decorator       Decorator
 	format          bool
 	validationStore ValidationStore
-
-	csrfProtection bool
+	locale          Locale
+	timeLocation    *time.Location
+	validators      map[ValidatorKey]Validator
+
+	csrfProtection        bool
+	honeypot              string
+	strictAccessKeys      bool
+	validateTags          bool
+	allowedTags           map[string]bool
+	skipUnsupportedFields bool
+
+	fieldWindowOffset int
+	fieldWindowLimit  int
+
+	fieldOrder         []string
+	hideUnlistedFields bool
+
+	formAttrs   []html.Attribute
+	submitLabel string
+
+	requiredProgress  bool
+	requiredTotal     int
+	requiredRemaining int
+
+	labelSuffix string
+	itemType    string
+	translator  Translator
+	idGenerator IDGenerator
+
+	nameFormatter NameFormatter
+	templates     *template.Template
+	resetControls bool
+
+	collectionRenderPolicy CollectionRenderPolicy
+	bracketNotation        bool
+	jsonMarshaler          JSONMarshaler
+	errorInLabel           bool
+	allHidden              bool
+	addressSections        map[string]string
+	elementNamePrefix      string
+	fullElementNames       bool
 }
 
 // NewEncoder returns a HTMLEncoder which outputs to w. A Decorator can be passed to NewEncoder, which will then be used
@@ -47,309 +92,1913 @@ func NewEncoder(w io.Writer, r *http.Request, decorator Decorator) *HTMLEncoder
 	decorator.RootNode(n)
 
 	return &HTMLEncoder{
-		w:               w,
-		r:               r,
-		n:               n,
-		decorator:       decorator,
-		ShowConditions:  make(ShowConditions),
-		validationStore: NewMemoryValidationStore(),
+		w:                  w,
+		r:                  r,
+		n:                  n,
+		decorator:          decorator,
+		ShowConditions:     make(ShowConditions),
+		RequiredConditions: make(RequiredConditions),
+		validationStore:    NewMemoryValidationStore(),
+		validators:         make(map[ValidatorKey]Validator),
 	}
 }
 
-// SetFormat tells the HTMLEncoder to output formatted HTML.
-// Formatting is provided by the https://github.com/yosssi/gohtml package.
-func (h *HTMLEncoder) SetFormat(b bool) {
-	h.format = b
-}
+// EncoderOption configures a HTMLEncoder. See NewEncoderWithOptions.
+type EncoderOption func(h *HTMLEncoder)
 
-// SetCSRFProtection can be used to enable CSRF protection. The gorilla/csrf middleware must be loaded, or
-// the Encode call will fail. SetCSRFProtection must also be enabled on the HTTPDecoder.
-// Validation of CSRF tokens is handled by the gorilla/csrf middleware, not formulate.
-func (h *HTMLEncoder) SetCSRFProtection(enabled bool) {
-	h.csrfProtection = enabled
-}
+// NewEncoderWithOptions returns a HTMLEncoder which outputs to w, configured by the given EncoderOptions.
+// It is equivalent to calling NewEncoder followed by the corresponding Set methods, e.g.
+//
+//	NewEncoderWithOptions(w, WithRequest(r), WithDecorator(decorators.BootstrapDecorator{}), WithCSRF(true))
+func NewEncoderWithOptions(w io.Writer, opts ...EncoderOption) *HTMLEncoder {
+	h := NewEncoder(w, nil, nil)
 
-// SetValidationStore can be used to tell the HTMLEncoder about previous validation errors.
-func (h *HTMLEncoder) SetValidationStore(v ValidationStore) {
-	if v == nil {
-		return
+	for _, opt := range opts {
+		opt(h)
 	}
 
-	h.validationStore = v
+	return h
 }
 
-func errorIncorrectValue(t reflect.Type) error {
-	return fmt.Errorf("formulate: encode expects a struct value, got: %s", t.String())
+// WithRequest sets the *http.Request which the form is being rendered for. A request is required for CSRF protection.
+func WithRequest(r *http.Request) EncoderOption {
+	return func(h *HTMLEncoder) {
+		h.r = r
+	}
 }
 
-// Encode takes a struct (or struct pointer) and produces an HTML form from all elements in the struct.
-// The encoder deals with most simple types and structs, but more complex types (maps, slices, arrays)
-// will render as a JSON blob in a <textarea>.
-//
-// The rendering behavior of any element can be replaced by implementing the CustomEncoder interface.
-// Encode calls will clear the ValidationStore, regardless of error state.
-func (h *HTMLEncoder) Encode(i interface{}) (err error) {
-	defer func() {
-		clearValidationStoreErr := h.validationStore.ClearValidationErrors()
-
-		if err == nil {
-			err = clearValidationStoreErr
+// WithDecorator sets the Decorator used to style the outputted HTML. See NewEncoder.
+func WithDecorator(decorator Decorator) EncoderOption {
+	return func(h *HTMLEncoder) {
+		if decorator == nil {
+			decorator = nilDecorator{}
 		}
-	}()
 
-	v := reflect.ValueOf(i)
+		h.decorator = decorator
+		h.decorator.RootNode(h.n)
+	}
+}
 
-	if err := h.validationStore.GetFormValue(i); err == nil && i != nil {
-		v = reflect.ValueOf(i)
+// WithFormat is the EncoderOption equivalent of HTMLEncoder.SetFormat.
+func WithFormat(b bool) EncoderOption {
+	return func(h *HTMLEncoder) {
+		h.SetFormat(b)
 	}
+}
 
-	if v.Kind() == reflect.Ptr {
-		if !v.IsValid() || v.Elem().Kind() != reflect.Struct {
-			return errorIncorrectValue(v.Type())
-		}
-	} else if v.Kind() != reflect.Struct {
-		return errorIncorrectValue(v.Type())
+// WithCSRF is the EncoderOption equivalent of HTMLEncoder.SetCSRFProtection.
+func WithCSRF(enabled bool) EncoderOption {
+	return func(h *HTMLEncoder) {
+		h.SetCSRFProtection(enabled)
 	}
+}
 
-	if err := h.recurse(v, v.Type().String(), StructField{}, h.n); err != nil {
-		return err
+// WithValidationStore is the EncoderOption equivalent of HTMLEncoder.SetValidationStore.
+func WithValidationStore(v ValidationStore) EncoderOption {
+	return func(h *HTMLEncoder) {
+		h.SetValidationStore(v)
 	}
+}
 
-	if h.csrfProtection && h.r != nil {
-		if err := h.buildCSRFTokenField(h.n); err != nil {
-			return err
-		}
+// WithForm is the EncoderOption equivalent of HTMLEncoder.SetForm.
+func WithForm(action, method string, attrs ...html.Attribute) EncoderOption {
+	return func(h *HTMLEncoder) {
+		h.SetForm(action, method, attrs...)
 	}
+}
 
-	if !h.format {
-		return html.Render(h.w, h.n)
+// WithLocale is the EncoderOption equivalent of HTMLEncoder.SetLocale.
+func WithLocale(locale Locale) EncoderOption {
+	return func(h *HTMLEncoder) {
+		h.SetLocale(locale)
 	}
+}
 
-	buf := new(bytes.Buffer)
+// WithElementNamePrefix is the EncoderOption equivalent of HTMLEncoder.SetElementNamePrefix.
+func WithElementNamePrefix(prefix string) EncoderOption {
+	return func(h *HTMLEncoder) {
+		h.SetElementNamePrefix(prefix)
+	}
+}
 
-	if err := html.Render(buf, h.n); err != nil {
-		return err
+// WithFullElementNames is the EncoderOption equivalent of HTMLEncoder.SetFullElementNames.
+func WithFullElementNames(b bool) EncoderOption {
+	return func(h *HTMLEncoder) {
+		h.SetFullElementNames(b)
 	}
+}
 
-	if _, err := h.w.Write(gohtml.FormatBytes(buf.Bytes())); err != nil {
-		return err
+// SetFormat tells the HTMLEncoder to output formatted HTML.
+// Formatting is provided by the https://github.com/yosssi/gohtml package.
+func (h *HTMLEncoder) SetFormat(b bool) {
+	h.format = b
+}
+
+// SetLocale sets the Locale used to format numbers and times which are displayed as text (type:"text") in read only
+// or disabled fields. Editable fields and inputs which require machine readable values are not affected, so that
+// their values can be decoded by the HTTPDecoder.
+func (h *HTMLEncoder) SetLocale(locale Locale) {
+	h.locale = locale
+}
+
+// SetForm tells the HTMLEncoder to wrap its output in a <form> with the given action and method. Any other attributes
+// of the form, such as its id or enctype, can be given as attrs. The CSRF token field and the submit button (see
+// SetSubmitButton) are rendered inside the form. By default, no <form> is rendered, and the output must be wrapped
+// in a <form> by the caller.
+func (h *HTMLEncoder) SetForm(action, method string, attrs ...html.Attribute) {
+	h.formAttrs = append([]html.Attribute{
+		{
+			Key: "action",
+			Val: action,
+		},
+		{
+			Key: "method",
+			Val: method,
+		},
+	}, attrs...)
+}
+
+// SetRequiredProgress tells the HTMLEncoder to render a summary of how many required fields are still empty
+// (e.g. "3 of 10 required fields remaining") before the form fields. Fields hidden by show conditions are not
+// counted. The summary can be styled by a Decorator which implements RequiredProgressDecorator.
+func (h *HTMLEncoder) SetRequiredProgress(b bool) {
+	h.requiredProgress = b
+}
+
+// RequiredProgressFormat is the format of the summary rendered by HTMLEncoder.SetRequiredProgress. It is passed
+// the number of remaining required fields, followed by the total number of required fields.
+var RequiredProgressFormat = "%d of %d required fields remaining"
+
+// buildRequiredProgress builds the summary of remaining required fields.
+func (h *HTMLEncoder) buildRequiredProgress() *html.Node {
+	n := &html.Node{
+		Type: html.ElementNode,
+		Data: "div",
 	}
 
-	return nil
+	n.AppendChild(&html.Node{
+		Type: html.TextNode,
+		Data: fmt.Sprintf(RequiredProgressFormat, h.requiredRemaining, h.requiredTotal),
+	})
+
+	if decorator, ok := h.decorator.(RequiredProgressDecorator); ok {
+		decorator.RequiredProgress(n, h.requiredRemaining, h.requiredTotal)
+	}
+
+	return n
 }
 
-func (h *HTMLEncoder) recurse(v reflect.Value, key string, field StructField, parent *html.Node) error {
-	if !field.IsExported() {
-		return nil
+// countRequired adds the field to the required field counts, if it is required and visible.
+func (h *HTMLEncoder) countRequired(v reflect.Value, field StructField) {
+	if !field.Required() || field.Computed() != "" || field.Disabled() || field.Hidden(h.ShowConditions) {
+		return
 	}
 
-	if v.CanInterface() {
-		switch v.Interface().(type) {
-		case time.Time, Select, RadioList, CustomEncoder:
-			return BuildField(v, FormElementName(key), field, parent, h.decorator, h.ShowConditions)
-		}
+	if field.OmitEmpty() && v.IsZero() {
+		// empty fields with the omitempty tag are not rendered.
+		return
 	}
 
-	switch v.Kind() {
-	case reflect.Ptr:
-		if v.IsNil() && v.CanAddr() {
-			v.Set(reflect.New(v.Type().Elem()))
-		}
+	h.requiredTotal++
 
-		return h.recurse(v.Elem(), key, field, parent)
-	case reflect.Interface:
-		return h.recurse(v.Elem(), key, field, parent)
-	case reflect.Struct:
-		if field.Hidden(h.ShowConditions) {
-			return nil
-		}
+	if v.IsZero() {
+		h.requiredRemaining++
+	}
+}
 
-		container := &html.Node{Type: html.ElementNode, Data: "div"}
+// SetSubmitButton tells the HTMLEncoder to render a submit button with the given label after the form fields.
+// The submit button is only rendered inside the <form> element, so SetForm must also be used.
+func (h *HTMLEncoder) SetSubmitButton(label string) {
+	h.submitLabel = label
+}
 
-		for i := 0; i < v.NumField(); i++ {
-			structField := v.Type().Field(i)
+// SetItemType sets the microdata item type of the form, e.g. "https://schema.org/Person". The root node is given the
+// itemscope and itemtype attributes, so that the itemprop struct tags of its fields describe properties of the item.
+func (h *HTMLEncoder) SetItemType(itemType string) {
+	h.itemType = itemType
+}
 
-			nextKey := key + fieldSeparator + v.Type().Field(i).Name
+// SetNameFormatter sets the NameFormatter used to display the names of fields which don't have a name tag, e.g.
+// to display acronyms or product names in a particular way. Custom formatters can fall back to CamelCaseName,
+// which is used by default.
+func (h *HTMLEncoder) SetNameFormatter(nameFormatter NameFormatter) {
+	h.nameFormatter = nameFormatter
+}
 
-			validationErrors, err := h.validationStore.GetValidationErrors(FormElementName(nextKey))
+// SetIDGenerator sets the IDGenerator used to generate the id attributes of form elements from their keys, e.g. to
+// namespace ids when multiple forms are rendered on one page, or to remove the dots from ids of nested fields. The
+// for attributes of labels, and ids derived from the element id (such as LabelID and HelpTextID) use the generated
+// id. The names of form elements are unaffected, so forms decode as normal. By default, the key is used as the id.
+func (h *HTMLEncoder) SetIDGenerator(idGenerator IDGenerator) {
+	h.idGenerator = idGenerator
+}
 
-			if err != nil {
-				return err
-			}
+// SetTranslator sets a Translator which is used to translate the labels, help text and option labels of fields,
+// so that a form can be rendered in multiple languages. The translator is given a key identifying the text and the
+// text which would otherwise be displayed. Keys are based on the path of the field within the form (e.g. "Address.City"):
+//
+//   - labels use the path of the field, e.g. "Address.City"
+//   - help text uses the path followed by ".help", e.g. "Address.City.help"
+//   - hints use the path followed by ".hint", e.g. "Address.City.hint"
+//   - option labels use the path followed by ".options." and the option's value, e.g. "Address.Country.options.GB"
+//
+// Empty labels, hints and help text are not translated. By default, no translation takes place.
+func (h *HTMLEncoder) SetTranslator(translator Translator) {
+	h.translator = translator
+}
 
-			err = h.recurse(
-				v.Field(i),
-				nextKey,
-				StructField{
-					StructField:      structField,
-					ValidationErrors: validationErrors,
-				},
-				container,
-			)
+// SetFieldResetControls tells the HTMLEncoder to render a reset control after the form element of each field with
+// a default tag. The form element is given the default value in a data-default attribute, and the reset control is a
+// <button type="button"> whose data-reset-for attribute is the id of the form element, so that client side scripts
+// can restore the default value. The reset control has no name, so it is not submitted. The reset control can be
+// styled by a Decorator which implements ResetControlDecorator.
+func (h *HTMLEncoder) SetFieldResetControls(b bool) {
+	h.resetControls = b
+}
 
-			if err != nil {
-				return err
-			}
-		}
+// ResetControlLabel is the label of the reset controls rendered by HTMLEncoder.SetFieldResetControls.
+var ResetControlLabel = "Reset"
 
-		if container.FirstChild != nil {
-			// only build wrappers or add children if elements were built into the container
-			// i.e. if all fields are hidden in this struct, don't display any furniture for it.
-			if field.BuildFieldset() {
-				fieldset := h.buildFieldSet(field, parent)
+// buildResetControl builds the reset control of the form element with the given key.
+func buildResetControl(key string, field StructField, decorator Decorator) *html.Node {
+	n := &html.Node{
+		Type: html.ElementNode,
+		Data: "button",
+		Attr: []html.Attribute{
+			{
+				Key: "type",
+				Val: "button",
+			},
+			{
+				Key: "data-reset-for",
+				Val: field.ElementID(key),
+			},
+		},
+	}
 
-				moveNodeChildren(container, fieldset)
-			} else {
-				moveNodeChildren(container, parent)
-			}
-		}
+	n.AppendChild(&html.Node{
+		Type: html.TextNode,
+		Data: ResetControlLabel,
+	})
 
-		return nil
-	case reflect.Slice, reflect.Array, reflect.Map:
-		buf := new(bytes.Buffer)
+	if decorator, ok := decorator.(ResetControlDecorator); ok {
+		decorator.ResetControl(n, field)
+	}
 
-		enc := json.NewEncoder(buf)
-		enc.SetIndent("", "  ")
+	return n
+}
 
-		if err := enc.Encode(v.Interface()); err != nil {
-			return err
-		}
+// CollectionRenderMode is how a slice, array or map field is rendered. See HTMLEncoder.SetCollectionRenderPolicy.
+type CollectionRenderMode int
+
+const (
+	// CollectionRenderJSON renders the field as JSON in a <textarea>.
+	CollectionRenderJSON CollectionRenderMode = iota
+	// CollectionRenderStructured renders the field with an input for each entry, if formulate has a structured
+	// editor for the field's type. Maps with string keys and values of a basic kind are rendered with an input for
+	// each entry (see BuildMapField), and slices are rendered as repeatable groups (see the elem:"repeat" tag).
+	// Arrays and other maps are rendered as JSON.
+	CollectionRenderStructured
+	// CollectionRenderSkip does not render the field. Skipped fields are not submitted, so they are left unchanged
+	// by the HTTPDecoder.
+	CollectionRenderSkip
+)
 
-		return h.recurse(reflect.ValueOf(Raw(buf.Bytes())), key, field, parent)
-	default:
-		return BuildField(v, FormElementName(key), field, parent, h.decorator, h.ShowConditions)
+// CollectionRenderPolicy returns the CollectionRenderMode of a slice, array or map field of the given kind.
+type CollectionRenderPolicy func(field StructField, kind reflect.Kind) CollectionRenderMode
+
+// SetCollectionRenderPolicy sets the policy which decides how each slice, array and map field is rendered. By default,
+// maps with the elem:"map" tag and slices with the elem:"repeat" tag are rendered as structured editors, and all
+// other collections are rendered as JSON.
+func (h *HTMLEncoder) SetCollectionRenderPolicy(policy CollectionRenderPolicy) {
+	h.collectionRenderPolicy = policy
+}
+
+// collectionRenderMode returns the CollectionRenderMode of a field of the collection type t. CollectionRenderStructured
+// is only returned if t has a structured editor.
+func (h *HTMLEncoder) collectionRenderMode(field StructField, t reflect.Type) CollectionRenderMode {
+	mode := CollectionRenderJSON
+
+	if h.collectionRenderPolicy != nil {
+		mode = h.collectionRenderPolicy(field, t.Kind())
+	} else if field.Elem() == "map" || field.Elem() == "repeat" {
+		mode = CollectionRenderStructured
+	}
+
+	if mode == CollectionRenderStructured && t.Kind() != reflect.Slice && !(t.Kind() == reflect.Map && isEditableMap(t)) {
+		return CollectionRenderJSON
 	}
+
+	return mode
 }
 
-func (h *HTMLEncoder) buildFieldSet(field StructField, parent *html.Node) *html.Node {
-	n := &html.Node{
-		Type: html.ElementNode,
-		Data: "fieldset",
+// JSONMarshaler encodes the value of a slice, array or map field as JSON. See HTMLEncoder.SetJSONMarshaler.
+type JSONMarshaler func(v interface{}) ([]byte, error)
+
+// SetJSONMarshaler sets the function used to encode slice, array and map fields which are rendered as JSON in a
+// <textarea>. By default, values are encoded using encoding/json, indented with two spaces. A JSONMarshaler could
+// be used to change the indentation, or to disable HTML escaping with json.Encoder.SetEscapeHTML. Forms encoded with
+// a JSONMarshaler may need a matching HTTPDecoder.SetJSONUnmarshaler.
+func (h *HTMLEncoder) SetJSONMarshaler(marshaler JSONMarshaler) {
+	h.jsonMarshaler = marshaler
+}
+
+// marshalJSON encodes v using the HTMLEncoder's JSONMarshaler, if one is set.
+func (h *HTMLEncoder) marshalJSON(v interface{}) ([]byte, error) {
+	if h.jsonMarshaler != nil {
+		return h.jsonMarshaler(v)
 	}
 
-	name := field.GetName()
+	buf := new(bytes.Buffer)
 
-	if name != "" {
-		legend := &html.Node{
-			Type: html.ElementNode,
-			Data: "legend",
-		}
+	enc := json.NewEncoder(buf)
+	enc.SetIndent("", "  ")
 
-		legend.AppendChild(&html.Node{
-			Type: html.TextNode,
-			Data: name,
-		})
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
 
-		n.AppendChild(legend)
+	return buf.Bytes(), nil
+}
+
+// SetErrorInLabel tells the HTMLEncoder to render the first validation error of each field within its <label>,
+// rather than in a separate element below the field, for compact layouts. The error is rendered in a <span>, which
+// can be styled by a Decorator which implements LabelErrorDecorator.
+func (h *HTMLEncoder) SetErrorInLabel(b bool) {
+	h.errorInLabel = b
+}
+
+// SetAllHidden tells the HTMLEncoder to render every field as an <input type="hidden">, with no labels or other
+// markup, so that the whole struct can be carried across a redirect or a multi-step form and re-submitted verbatim.
+// The hidden inputs hold the values the visible form would submit, so they can be read back by a HTTPDecoder
+// configured in the same way as the HTMLEncoder.
+func (h *HTMLEncoder) SetAllHidden(b bool) {
+	h.allHidden = b
+}
+
+// SetAddressSection scopes the autocomplete tokens (see StructField.Autocomplete) of all of the fields within the
+// nested struct at fieldPath to the given section, e.g. SetAddressSection("Billing", "billing") renders
+// autocomplete="section-billing postal-code" for a Billing.PostCode field with autocomplete:"postal-code". This lets
+// browsers autofill forms which contain more than one address. fieldPath is the form element name of the struct
+// field, e.g. "Order.Billing". Sections of nested structs take precedence over the sections of their parents.
+func (h *HTMLEncoder) SetAddressSection(fieldPath, section string) {
+	if h.addressSections == nil {
+		h.addressSections = make(map[string]string)
 	}
 
-	parent.AppendChild(n)
-	h.decorator.Fieldset(n, field)
+	h.addressSections[fieldPath] = section
+}
 
-	return n
+// SetBracketNotation tells the HTMLEncoder to name the form elements of nested struct fields using bracket notation,
+// e.g. Address[HouseName] rather than Address.HouseName, as used by some client side form serializers. Forms rendered
+// with bracket notation should be decoded by a HTTPDecoder with HTTPDecoder.SetBracketNotation enabled.
+func (h *HTMLEncoder) SetBracketNotation(b bool) {
+	h.bracketNotation = b
 }
 
-func BuildField(v reflect.Value, key string, field StructField, parent *html.Node, decorator Decorator, showConditions ShowConditions) error {
-	if !v.IsValid() || field.Hidden(showConditions) {
-		return nil
+// SetElementNamePrefix sets a prefix which is added to the name (and so the id) of every form element, e.g. the
+// prefix "billing-" renders the field Address.PostCode as billing-Address.PostCode. This allows more than one form
+// for the same struct type on a page. Forms rendered with a prefix should be decoded by a HTTPDecoder with the same
+// prefix set using HTTPDecoder.SetElementNamePrefix.
+func (h *HTMLEncoder) SetElementNamePrefix(prefix string) {
+	h.elementNamePrefix = prefix
+}
+
+// SetFullElementNames tells the HTMLEncoder not to remove the root key (the package and name of the encoded struct
+// type, e.g. "formulate.Person") from the names of form elements, so that the field Address.HouseNumber is rendered
+// as formulate.Person.Address.HouseNumber. Anonymous structs have the root key "anonymous.struct". Forms rendered with
+// full element names should be decoded by a HTTPDecoder with HTTPDecoder.SetFullElementNames enabled.
+func (h *HTMLEncoder) SetFullElementNames(b bool) {
+	h.fullElementNames = b
+}
+
+// formElementName returns the name of the form element with the given key.
+func (h *HTMLEncoder) formElementName(key string) string {
+	name := FormElementName(key)
+
+	if h.bracketNotation {
+		name = bracketElementName(name)
 	}
 
-	var wrapper *html.Node
+	if h.fullElementNames {
+		name = strings.TrimSuffix(key, FormElementName(key)) + name
+	}
 
-	if field.InputType("") == "hidden" {
-		// hidden input fields have no other page furniture.
-		wrapper = parent
-	} else {
-		rowElement := &html.Node{
-			Type: html.ElementNode,
-			Data: "div",
-		}
+	return h.elementNamePrefix + name
+}
 
-		BuildLabel(key, rowElement, field, decorator)
-		wrapper = &html.Node{
-			Type: html.ElementNode,
-			Data: "div",
-		}
+// bracketElementName converts the dotted form element name to bracket notation, e.g. Address.HouseName
+// becomes Address[HouseName].
+func bracketElementName(name string) string {
+	parts := strings.Split(name, fieldSeparator)
 
-		rowElement.AppendChild(wrapper)
-		decorator.FieldWrapper(wrapper, field)
+	if len(parts) == 1 {
+		return name
+	}
 
-		parent.AppendChild(rowElement)
+	return parts[0] + "[" + strings.Join(parts[1:], "][") + "]"
+}
 
-		defer func() {
-			if len(field.ValidationErrors) > 0 {
-				BuildValidationText(wrapper, field, decorator)
-			}
+// SetTemplates sets the templates used to render fields with a template tag. The template named by the tag is
+// executed with a FieldTemplateData, and its output replaces the element that formulate would otherwise build. The
+// label, help text and validation errors of the field are still rendered around the template's output.
+//
+// For example, a field with the tag template:"colourPicker" could be rendered by:
+//
+//	{{ define "colourPicker" }}
+//	  <input type="color" name="{{ .Name }}" id="{{ .ID }}" value="{{ .Value }}">
+//	{{ end }}
+//
+// Templated fields are decoded as normal, so the template should submit a value which can be parsed as the field's type.
+func (h *HTMLEncoder) SetTemplates(templates *template.Template) {
+	h.templates = templates
+}
 
-			BuildHelpText(wrapper, field, decorator)
-			decorator.Row(rowElement, field)
-		}()
+// FieldTemplateData is passed to the template of a field with a template tag. See HTMLEncoder.SetTemplates.
+type FieldTemplateData struct {
+	// Value is the value of the field.
+	Value interface{}
+	// Label is the label of the field.
+	Label string
+	// Name and ID are the name and id attributes which the field's form element should use.
+	Name string
+	ID   string
+	// Errors are the validation errors of the field, if any.
+	Errors []ValidationError
+	// Field is the StructField being rendered.
+	Field StructField
+}
+
+// buildTemplateField renders v into parent using the template named by the field's template tag.
+func buildTemplateField(v reflect.Value, key string, field StructField, parent *html.Node) error {
+	name := field.Template()
+
+	if field.templates == nil || field.templates.Lookup(name) == nil {
+		return fmt.Errorf("%w: %s (%s)", ErrUnknownTemplate, name, key)
+	}
+
+	data := FieldTemplateData{
+		Label:  field.translate("", field.GetName()),
+		Name:   key,
+		ID:     field.ElementID(key),
+		Errors: field.ValidationErrors,
+		Field:  field,
 	}
 
 	if v.CanInterface() {
-		switch a := v.Interface().(type) {
-		case CustomEncoder:
-			return a.BuildFormElement(key, wrapper, field, decorator)
-		case time.Time:
-			n := BuildTimeField(a, key, field)
-			wrapper.AppendChild(n)
-			decorator.NumberField(n, field)
-			return nil
-		case Select:
-			n := BuildSelectField(a, key)
-			wrapper.AppendChild(n)
-			decorator.SelectField(n, field)
-			return nil
-		case RadioList:
-			n := BuildRadioButtons(a, key, field, decorator)
-			wrapper.AppendChild(n)
-			return nil
-		}
+		data.Value = v.Interface()
+	}
+
+	buf := new(bytes.Buffer)
+
+	if err := field.templates.ExecuteTemplate(buf, name, data); err != nil {
+		return err
+	}
+
+	container := &html.Node{
+		Type: html.ElementNode,
+		Data: "div",
+	}
+
+	if err := RenderHTMLToNode(template.HTML(buf.String()), container); err != nil {
+		return err
+	}
+
+	moveNodeChildren(container, parent)
+
+	return nil
+}
+
+// SetLabelSuffix sets a suffix, such as ":", which is appended to the label of every field. The suffix is added as
+// a separate text node within the <label>. Fields without a label are unaffected. By default, there is no suffix.
+func (h *HTMLEncoder) SetLabelSuffix(suffix string) {
+	h.labelSuffix = suffix
+}
+
+// SetTimeLocation sets the location which time.Time values are displayed in. This should match the location
+// set on the HTTPDecoder with HTTPDecoder.SetTimeLocation. The tz struct tag can be used to override the location
+// of a single field. By default, time.Time values are displayed in their own location.
+func (h *HTMLEncoder) SetTimeLocation(loc *time.Location) {
+	h.timeLocation = loc
+}
+
+// AddValidators registers Validators with the HTMLEncoder. Validators which implement HTMLAttributeValidator
+// add their HTML attributes to the form elements of fields which use them (see the validators struct tag).
+// Other Validators are ignored by the HTMLEncoder. Validators must still be added to the HTTPDecoder to be run.
+func (h *HTMLEncoder) AddValidators(validators ...Validator) {
+	for _, validator := range validators {
+		h.validators[ValidatorKey(validator.TagName())] = validator
+	}
+}
+
+// SetCSRFProtection can be used to enable CSRF protection. The gorilla/csrf middleware must be loaded, or
+// the Encode call will fail. SetCSRFProtection must also be enabled on the HTTPDecoder.
+// Validation of CSRF tokens is handled by the gorilla/csrf middleware, not formulate.
+func (h *HTMLEncoder) SetCSRFProtection(enabled bool) {
+	h.csrfProtection = enabled
+}
+
+// SetHoneypot tells the HTMLEncoder to render a honeypot input with the given name. The input is hidden from
+// users (and assistive technologies), so it is left empty by people but is often filled in by spam bots. The
+// HTTPDecoder must be given the same name with HTTPDecoder.SetHoneypot, so that it rejects forms which fill it in.
+// The name should not be the name of any other form element.
+func (h *HTMLEncoder) SetHoneypot(name string) {
+	h.honeypot = name
+}
+
+// SetStrictAccessKeys tells the HTMLEncoder to return an ErrInvalidAccessKey if a field has an accesskey tag which
+// is not a single character. By default, invalid access keys are ignored.
+func (h *HTMLEncoder) SetStrictAccessKeys(b bool) {
+	h.strictAccessKeys = b
+}
+
+// SetValidateTags tells the HTMLEncoder to return an ErrUnknownTag if a field has a struct tag key which is not
+// one of formulate's tags (see StructField), e.g. a misspelling such as requird:"true". Struct tags used by other
+// packages, such as json, must be listed in allowedKeys. By default, unknown tags are ignored.
+func (h *HTMLEncoder) SetValidateTags(b bool, allowedKeys ...string) {
+	h.validateTags = b
+	h.allowedTags = make(map[string]bool, len(allowedKeys))
+
+	for _, key := range allowedKeys {
+		h.allowedTags[key] = true
+	}
+}
+
+// SetSkipUnsupportedFields tells the HTMLEncoder to skip fields of kinds which cannot be rendered
+// (e.g. funcs, channels and complex numbers). By default, Encode returns an ErrUnsupportedKind for these fields.
+func (h *HTMLEncoder) SetSkipUnsupportedFields(b bool) {
+	h.skipUnsupportedFields = b
+}
+
+// SetFieldWindow limits the encoder to rendering a window of the top-level fields of the encoded struct.
+// Fields are counted in render order (see SetFieldOrder), starting at offset, and at most limit fields are rendered.
+// A limit of 0 or less renders all fields from offset onwards. This can be used to split a large form
+// across multiple pages. The HTTPDecoder only decodes values which are present in the form, so each
+// page can be decoded into the same struct.
+func (h *HTMLEncoder) SetFieldWindow(offset, limit int) {
+	h.fieldWindowOffset = offset
+	h.fieldWindowLimit = limit
+}
+
+// inFieldWindow determines if the top-level field at index i should be rendered.
+func (h *HTMLEncoder) inFieldWindow(i int) bool {
+	if i < h.fieldWindowOffset {
+		return false
+	}
+
+	return h.fieldWindowLimit <= 0 || i < h.fieldWindowOffset+h.fieldWindowLimit
+}
+
+// SetFieldOrder sets the order in which the top-level fields of the encoded struct are rendered, by field name.
+// Fields which are not in order are rendered afterwards in the order given by their order tags (see StructField),
+// unless SetHideUnlistedFields is used.
+// Names which do not match a field are ignored. Decoding is unaffected by the field order.
+func (h *HTMLEncoder) SetFieldOrder(order []string) {
+	h.fieldOrder = order
+}
+
+// SetHideUnlistedFields tells the HTMLEncoder not to render top-level fields which are not in the field order
+// given to SetFieldOrder. It has no effect if no field order is set.
+func (h *HTMLEncoder) SetHideUnlistedFields(b bool) {
+	h.hideUnlistedFields = b
+}
+
+// orderedFieldIndexes returns the indexes of fields in the order in which they should be rendered.
+func (h *HTMLEncoder) orderedFieldIndexes(fields []StructField) []int {
+	if len(h.fieldOrder) == 0 {
+		return taggedFieldIndexes(fields)
+	}
+
+	indexes := make([]int, 0, len(fields))
+
+	listed := make(map[int]bool, len(h.fieldOrder))
+
+	for _, name := range h.fieldOrder {
+		for i, field := range fields {
+			if field.Name == name && !listed[i] {
+				listed[i] = true
+				indexes = append(indexes, i)
+				break
+			}
+		}
+	}
+
+	if h.hideUnlistedFields {
+		return indexes
+	}
+
+	for _, i := range taggedFieldIndexes(fields) {
+		if !listed[i] {
+			indexes = append(indexes, i)
+		}
+	}
+
+	return indexes
+}
+
+// taggedFieldIndexes returns the indexes of fields sorted by their order tags. Fields without an order tag
+// follow the ordered fields, in declaration order.
+func taggedFieldIndexes(fields []StructField) []int {
+	indexes := make([]int, len(fields))
+
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	sort.SliceStable(indexes, func(a, b int) bool {
+		orderA, okA := fields[indexes[a]].Order()
+		orderB, okB := fields[indexes[b]].Order()
+
+		if okA != okB {
+			return okA
+		}
+
+		return okA && orderA < orderB
+	})
+
+	return indexes
+}
+
+// SetValidationStore can be used to tell the HTMLEncoder about previous validation errors.
+func (h *HTMLEncoder) SetValidationStore(v ValidationStore) {
+	if v == nil {
+		return
+	}
+
+	h.validationStore = v
+}
+
+func errorIncorrectValue(t reflect.Type) error {
+	return fmt.Errorf("formulate: encode expects a struct value, got: %s", t.String())
+}
+
+// Encode takes a struct (or struct pointer) and produces an HTML form from all elements in the struct.
+// The encoder deals with most simple types and structs, but more complex types (maps, slices, arrays)
+// will render as a JSON blob in a <textarea>. Fields of kinds which cannot be rendered (e.g. funcs and channels)
+// cause an ErrUnsupportedKind to be returned, unless SetSkipUnsupportedFields is enabled.
+//
+// The rendering behavior of any element can be replaced by implementing the CustomEncoder interface.
+// Encode calls will clear the ValidationStore, regardless of error state.
+func (h *HTMLEncoder) Encode(i interface{}) error {
+	return h.EncodeContext(context.Background(), i)
+}
+
+// EncodeContext is the same as Encode, but stops encoding and returns ctx.Err() if ctx is cancelled
+// before encoding is complete.
+func (h *HTMLEncoder) EncodeContext(ctx context.Context, i interface{}) (err error) {
+	defer func() {
+		clearValidationStoreErr := h.validationStore.ClearValidationErrors()
+
+		if err == nil {
+			err = clearValidationStoreErr
+		}
+	}()
+
+	v := reflect.ValueOf(i)
+
+	if err := h.validationStore.GetFormValue(i); err == nil && i != nil {
+		v = reflect.ValueOf(i)
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if !v.IsValid() || v.Elem().Kind() != reflect.Struct {
+			return errorIncorrectValue(v.Type())
+		}
+	} else if v.Kind() != reflect.Struct {
+		return errorIncorrectValue(v.Type())
+	}
+
+	h.requiredTotal, h.requiredRemaining = 0, 0
+
+	// the form is built within a copy of the decorated root node, so that fields from a previous call are not rendered
+	// again when the HTMLEncoder is reused.
+	n := &html.Node{
+		Type: h.n.Type,
+		Data: h.n.Data,
+		Attr: append([]html.Attribute(nil), h.n.Attr...),
+	}
+
+	if h.itemType != "" {
+		setAttribute(n, "itemscope", "")
+		setAttribute(n, "itemtype", h.itemType)
+	}
+
+	if err := h.recurse(ctx, v, rootKey(v.Type()), StructField{}, n); err != nil {
+		return err
+	}
+
+	if h.requiredProgress && h.requiredTotal > 0 {
+		n.InsertBefore(h.buildRequiredProgress(), n.FirstChild)
+	}
+
+	if h.csrfProtection && h.r != nil {
+		if err := h.buildCSRFTokenField(n); err != nil {
+			return err
+		}
+	}
+
+	if h.honeypot != "" {
+		n.AppendChild(buildHoneypot(h.honeypot))
+	}
+
+	if h.allHidden {
+		hideFormControls(n)
+	}
+
+	root := n
+
+	if h.formAttrs != nil {
+		if h.submitLabel != "" {
+			button := buildSubmitButton(h.submitLabel)
+			n.AppendChild(button)
+
+			if decorator, ok := h.decorator.(SubmitButtonDecorator); ok {
+				decorator.SubmitButton(button)
+			}
+		}
+
+		root = &html.Node{
+			Type: html.ElementNode,
+			Data: "form",
+			Attr: append([]html.Attribute(nil), h.formAttrs...),
+		}
+
+		root.AppendChild(n)
+	}
+
+	if !h.format {
+		return html.Render(h.w, root)
+	}
+
+	buf := new(bytes.Buffer)
+
+	if err := html.Render(buf, root); err != nil {
+		return err
+	}
+
+	if _, err := h.w.Write(gohtml.FormatBytes(buf.Bytes())); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (h *HTMLEncoder) recurse(ctx context.Context, v reflect.Value, key string, field StructField, parent *html.Node) error {
+	if !field.IsExported() {
+		return nil
+	}
+
+	if field.OmitEmpty() && v.IsValid() && v.IsZero() {
+		return nil
+	}
+
+	if field.Template() != "" {
+		return BuildField(v, h.formElementName(key), field, parent, h.decorator, h.ShowConditions)
+	}
+
+	if v.CanInterface() {
+		switch v.Interface().(type) {
+		case time.Time, big.Rat, Flags, Select, Enum, EnumOptions, RadioList, CheckboxGroup, CustomEncoder:
+			return BuildField(v, h.formElementName(key), field, parent, h.decorator, h.ShowConditions)
+		case OptionalValue:
+			return h.recurseOptional(ctx, v, key, field, parent)
+		}
+
+		if isTextType(v.Type()) {
+			return BuildField(v, h.formElementName(key), field, parent, h.decorator, h.ShowConditions)
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() && isCollectionKind(v.Type().Elem().Kind()) && !isTextType(v.Type().Elem()) {
+			// a nil pointer to a slice or map is absent, so it is rendered empty rather than allocated.
+			elem := reflect.Zero(v.Type().Elem())
+
+			if h.collectionRenderMode(field, elem.Type()) != CollectionRenderJSON {
+				return h.recurse(ctx, elem, key, field, parent)
+			}
+
+			return h.recurse(ctx, reflect.ValueOf(Raw(nil)), key, field, parent)
+		}
+
+		if v.IsNil() && isSupportedKind(v.Type().Elem().Kind()) {
+			// a nil pointer to a scalar is absent, so it is rendered as an empty input rather than allocated.
+			return h.recurseEmpty(ctx, reflect.New(v.Type().Elem()).Elem(), key, field, parent)
+		}
+
+		if v.IsNil() && v.CanAddr() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return h.recurse(ctx, v.Elem(), key, field, parent)
+	case reflect.Interface:
+		return h.recurse(ctx, v.Elem(), key, field, parent)
+	case reflect.Struct:
+		if field.Hidden(h.ShowConditions) {
+			return nil
+		}
+
+		container := &html.Node{Type: html.ElementNode, Data: "div"}
+
+		structFields := cachedStructFields(v.Type())
+		var indexes []int
+
+		if field.Name == "" {
+			// only the top-level struct (which has no field name) is ordered by SetFieldOrder and windowed.
+			indexes = h.orderedFieldIndexes(structFields)
+		} else {
+			indexes = taggedFieldIndexes(structFields)
+		}
+
+		// consecutive fields with the same group tag are rendered into a shared group node.
+		var groupNode *html.Node
+		var groupName string
+		var groupNodes []*html.Node
+
+		for position, i := range indexes {
+			structField := structFields[i]
+
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			if field.Name == "" && !h.inFieldWindow(position) {
+				continue
+			}
+
+			if h.strictAccessKeys {
+				if err := validateAccessKey(structField); err != nil {
+					return err
+				}
+			}
+
+			if h.validateTags {
+				if err := validateTags(structField, h.allowedTags); err != nil {
+					return err
+				}
+			}
+
+			nextKey := key + fieldSeparator + structField.Name
+
+			validationErrors, err := h.validationStore.GetValidationErrors(FormElementName(nextKey))
+
+			if err != nil {
+				return err
+			}
+
+			structField.ValidationErrors = validationErrors
+			structField.locale = h.locale
+			structField.timeLocation = h.timeLocation
+			structField.registeredValidators = h.validators
+			structField.labelSuffix = h.labelSuffix
+			structField.translator = h.translator
+			structField.idGenerator = h.idGenerator
+			structField.nameFormatter = h.nameFormatter
+			structField.path = FormElementName(nextKey)
+			structField.requiredConditions = h.RequiredConditions
+			structField.templates = h.templates
+			structField.resetControls = h.resetControls
+			structField.errorInLabel = h.errorInLabel
+			structField.autocompleteSection = field.autocompleteSection
+			structField.parent = v
+			structField.parentDisabled = field.Disabled()
+			structField.disabledFieldset = field.disabledFieldset || (field.Disabled() && field.BuildFieldset())
+
+			if section, ok := h.addressSections[structField.path]; ok {
+				structField.autocompleteSection = section
+			}
+
+			if h.requiredProgress {
+				h.countRequired(v.Field(i), structField)
+			}
+
+			fieldParent := container
+
+			if group := structField.Group(); group != "" {
+				if groupNode == nil || groupName != group {
+					groupNode, groupName = h.buildFieldGroup(group), group
+					container.AppendChild(groupNode)
+					groupNodes = append(groupNodes, groupNode)
+				}
+
+				fieldParent = groupNode
+			} else {
+				groupNode, groupName = nil, ""
+			}
+
+			if method := structField.Computed(); method != "" {
+				value, err := methodValue(v, method)
+
+				if err != nil {
+					return err
+				}
+
+				if err := h.recurse(ctx, reflect.ValueOf(ComputedValue(value)), nextKey, structField, fieldParent); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if method := structField.DisplayMethod(); method != "" && (structField.ReadOnly() || structField.Disabled()) {
+				value, err := methodValue(v, method)
+
+				if err != nil {
+					return err
+				}
+
+				if err := h.recurse(ctx, reflect.ValueOf(value), nextKey, structField, fieldParent); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if err := h.recurse(ctx, v.Field(i), nextKey, structField, fieldParent); err != nil {
+				return err
+			}
+		}
+
+		for _, groupNode := range groupNodes {
+			if groupNode.FirstChild == nil {
+				// all of the fields in the group were hidden.
+				container.RemoveChild(groupNode)
+			}
+		}
+
+		if container.FirstChild != nil {
+			// only build wrappers or add children if elements were built into the container
+			// i.e. if all fields are hidden in this struct, don't display any furniture for it.
+			if field.BuildFieldset() {
+				fieldset := h.buildFieldSet(field, parent)
+
+				moveNodeChildren(container, fieldset)
+			} else {
+				moveNodeChildren(container, parent)
+			}
+		}
+
+		return nil
+	case reflect.Slice, reflect.Array, reflect.Map:
+		switch h.collectionRenderMode(field, v.Type()) {
+		case CollectionRenderSkip:
+			return nil
+		case CollectionRenderStructured:
+			if v.Kind() == reflect.Slice {
+				return h.recurseRepeatGroup(ctx, v, key, field, parent)
+			}
+
+			return BuildField(v, h.formElementName(key), field, parent, h.decorator, h.ShowConditions)
+		}
+
+		b, err := h.marshalJSON(v.Interface())
+
+		if err != nil {
+			return err
+		}
+
+		return h.recurse(ctx, reflect.ValueOf(Raw(b)), key, field, parent)
+	default:
+		if h.skipUnsupportedFields && !isSupportedKind(v.Kind()) {
+			return nil
+		}
+
+		return BuildField(v, h.formElementName(key), field, parent, h.decorator, h.ShowConditions)
+	}
+}
+
+// RepeatIndexPlaceholder is the index used in the names of the form elements in the <template> of a repeatable
+// group. Client side scripts should replace it with the index of the new item when it is added.
+const RepeatIndexPlaceholder = "__index__"
+
+var (
+	// RepeatAddLabel is the label of the button which adds an item to a repeatable group.
+	RepeatAddLabel = "Add"
+	// RepeatRemoveLabel is the label of the button which removes an item from a repeatable group.
+	RepeatRemoveLabel = "Remove"
+)
+
+// recurseRepeatGroup renders the slice v as a repeatable group within a <fieldset>. Each element is rendered as an item named with its
+// index, e.g. Addresses[0].Street, followed by a <template> containing a blank item with the RepeatIndexPlaceholder
+// as its index, and a button to add an item. Each item has a button to remove it. The buttons have no behaviour
+// of their own, and are identified by data attributes for client side scripts:
+//
+//   - data-repeat is set on the group, to the name of the field.
+//   - data-repeat-item is set on each item, to its index.
+//   - data-repeat-template is set on the <template>, to the name of the field.
+//   - data-repeat-add is set on the add button, and data-repeat-remove on each remove button.
+//
+// The HTTPDecoder decodes whichever indexes are submitted, in index order. The group also contains an empty hidden
+// input named after the field, so that a group whose items have all been removed is decoded as an empty slice.
+func (h *HTMLEncoder) recurseRepeatGroup(ctx context.Context, v reflect.Value, key string, field StructField, parent *html.Node) error {
+	name := h.formElementName(key)
+
+	group := &html.Node{
+		Type: html.ElementNode,
+		Data: "div",
+		Attr: []html.Attribute{
+			{
+				Key: "id",
+				Val: field.ElementID(name),
+			},
+			{
+				Key: "data-repeat",
+				Val: name,
+			},
+		},
+	}
+
+	group.AppendChild(buildHiddenInput(name, ""))
+
+	for i := 0; i < v.Len(); i++ {
+		if err := h.buildRepeatItem(ctx, v.Index(i), key, strconv.Itoa(i), group); err != nil {
+			return err
+		}
+	}
+
+	tmpl := &html.Node{
+		Type: html.ElementNode,
+		Data: "template",
+		Attr: []html.Attribute{
+			{
+				Key: "data-repeat-template",
+				Val: name,
+			},
+		},
+	}
+
+	if err := h.buildRepeatItem(ctx, reflect.New(v.Type().Elem()).Elem(), key, RepeatIndexPlaceholder, tmpl); err != nil {
+		return err
+	}
+
+	group.AppendChild(tmpl)
+	group.AppendChild(buildRepeatButton("data-repeat-add", name, RepeatAddLabel))
+
+	h.buildFieldSet(field, parent).AppendChild(group)
+
+	return nil
+}
+
+// buildRepeatItem renders the element v of a repeatable group with the given index.
+func (h *HTMLEncoder) buildRepeatItem(ctx context.Context, v reflect.Value, key, index string, parent *html.Node) error {
+	item := &html.Node{
+		Type: html.ElementNode,
+		Data: "div",
+		Attr: []html.Attribute{
+			{
+				Key: "data-repeat-item",
+				Val: index,
+			},
+		},
+	}
+
+	itemKey := mapEntryName(key, index)
+
+	if err := h.recurse(ctx, v, itemKey, StructField{}, item); err != nil {
+		return err
+	}
+
+	item.AppendChild(buildRepeatButton("data-repeat-remove", h.formElementName(itemKey), RepeatRemoveLabel))
+	parent.AppendChild(item)
+
+	return nil
+}
+
+// buildRepeatButton builds a <button type="button"> with the given data attribute and label.
+func buildRepeatButton(attr, name, label string) *html.Node {
+	n := &html.Node{
+		Type: html.ElementNode,
+		Data: "button",
+		Attr: []html.Attribute{
+			{
+				Key: "type",
+				Val: "button",
+			},
+			{
+				Key: attr,
+				Val: name,
+			},
+		},
+	}
+
+	n.AppendChild(&html.Node{
+		Type: html.TextNode,
+		Data: label,
+	})
+
+	return n
+}
+
+// recurseOptional renders the value of the OptionalValue v. If the value is absent, the zero value is rendered,
+// and the value of its input is cleared.
+func (h *HTMLEncoder) recurseOptional(ctx context.Context, v reflect.Value, key string, field StructField, parent *html.Node) error {
+	getValue, err := optionalGetter(v)
+
+	if err != nil {
+		return err
+	}
+
+	out := getValue.Call(nil)
+	value := reflect.New(out[0].Type()).Elem()
+	value.Set(out[0])
+
+	if out[1].Bool() {
+		return h.recurse(ctx, value, key, field, parent)
+	}
+
+	return h.recurseEmpty(ctx, value, key, field, parent)
+}
+
+// recurseEmpty renders v, and then clears the value of its input, so that an absent value is rendered empty.
+func (h *HTMLEncoder) recurseEmpty(ctx context.Context, value reflect.Value, key string, field StructField, parent *html.Node) error {
+	container := &html.Node{
+		Type: html.ElementNode,
+		Data: "div",
+	}
+
+	if err := h.recurse(ctx, value, key, field, container); err != nil {
+		return err
+	}
+
+	clearValue(container, h.formElementName(key))
+	moveNodeChildren(container, parent)
+
+	return nil
+}
+
+// clearValue empties the value of the element named key within n.
+func clearValue(n *html.Node, key string) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && getAttribute(c, "name") == key {
+			switch c.Data {
+			case "input":
+				for i, attr := range c.Attr {
+					if attr.Key == "value" && getAttribute(c, "type") != "checkbox" && getAttribute(c, "type") != "radio" {
+						c.Attr[i].Val = ""
+					}
+				}
+			case "textarea":
+				for c.FirstChild != nil {
+					c.RemoveChild(c.FirstChild)
+				}
+			}
+		}
+
+		clearValue(c, key)
+	}
+}
+
+// methodValue calls the method of the struct v with the given name, returning its result as a string.
+func methodValue(v reflect.Value, method string) (string, error) {
+	fn := v.MethodByName(method)
+
+	if !fn.IsValid() && v.CanAddr() {
+		// the method may have a pointer receiver.
+		fn = v.Addr().MethodByName(method)
+	}
+
+	if !fn.IsValid() || fn.Type().NumIn() != 0 || fn.Type().NumOut() != 1 {
+		return "", fmt.Errorf("%w: %s.%s", ErrInvalidComputedField, v.Type(), method)
+	}
+
+	return toString(fn.Call(nil)[0].Interface()), nil
+}
+
+// validateAccessKey returns an ErrInvalidAccessKey if the field has an accesskey tag which is not a single character.
+func validateAccessKey(field StructField) error {
+	if field.tag("accesskey") != "" && field.AccessKey() == "" {
+		return fmt.Errorf("%w: %s", ErrInvalidAccessKey, field.Name)
+	}
+
+	return nil
+}
+
+// validateTags returns an ErrUnknownTag if the field has a struct tag key which is neither a formulate tag nor
+// one of the allowed keys.
+func validateTags(field StructField, allowed map[string]bool) error {
+	tags := parseTags(field.Tag)
+	keys := make([]string, 0, len(tags))
+
+	for key := range tags {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !knownTags[key] && !allowed[key] {
+			return fmt.Errorf("%w: %q on %s", ErrUnknownTag, key, field.Name)
+		}
+	}
+
+	return nil
+}
+
+func (h *HTMLEncoder) buildFieldSet(field StructField, parent *html.Node) *html.Node {
+	n := &html.Node{
+		Type: html.ElementNode,
+		Data: "fieldset",
+	}
+
+	name := field.translate("", field.GetName())
+
+	if name != "" {
+		legend := &html.Node{
+			Type: html.ElementNode,
+			Data: "legend",
+		}
+
+		legend.AppendChild(&html.Node{
+			Type: html.TextNode,
+			Data: name,
+		})
+
+		n.AppendChild(legend)
+	}
+
+	if field.Disabled() && !field.disabledFieldset {
+		n.Attr = append(n.Attr, html.Attribute{Key: "disabled"})
+	}
+
+	parent.AppendChild(n)
+	h.decorator.Fieldset(n, field)
+
+	return n
+}
+
+// EncodeField writes the HTML of a single field to w, e.g. for rendering a partial form in response to an AJAX request.
+// value is rendered as if it were a struct field with the given struct tag, e.g. `key:"DateOfBirth" name:"Date of birth"`.
+// The key tag is required, and is the name of the form element, which must match the name of the struct field that
+// the HTTPDecoder decodes it into (e.g. "Address.City" for a nested field). The label is taken from the name tag, as
+// for struct fields. If decorator is nil, no decorator is used. Structs, and fields which need the options of a
+// HTMLEncoder (such as show conditions and validators), should be rendered with a HTMLEncoder.
+func EncodeField(w io.Writer, value interface{}, tag reflect.StructTag, decorator Decorator) error {
+	if decorator == nil {
+		decorator = nilDecorator{}
+	}
+
+	v := reflect.ValueOf(value)
+
+	if !v.IsValid() {
+		return ErrNilFieldValue
+	}
+
+	key := tag.Get("key")
+
+	if key == "" {
+		return ErrMissingFieldKey
+	}
+
+	field := StructField{
+		StructField: reflect.StructField{
+			// fields without a name tag are labelled with the name of the field, e.g. "City" for "Address.City".
+			Name: key[strings.LastIndex(key, fieldSeparator)+1:],
+			Type: v.Type(),
+			Tag:  tag,
+		},
+	}
+
+	parent := &html.Node{
+		Type: html.ElementNode,
+		Data: "div",
+	}
+
+	if err := BuildField(v, key, field, parent, decorator, nil); err != nil {
+		return err
+	}
+
+	for n := parent.FirstChild; n != nil; n = n.NextSibling {
+		if err := html.Render(w, n); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func BuildField(v reflect.Value, key string, field StructField, parent *html.Node, decorator Decorator, showConditions ShowConditions) error {
+	if !v.IsValid() || field.Hidden(showConditions) {
+		return nil
+	}
+
+	raw, hasRaw := rawValidationErrorValue(field.ValidationErrors)
+
+	if len(field.ValidationErrors) > 0 && !hasRaw {
+		// render the value which failed validation, so that the user sees exactly what they entered.
+		v = validationErrorValue(v, field.ValidationErrors)
+	}
+
+	var wrapper *html.Node
+
+	if field.InputType("") == "hidden" {
+		// hidden input fields have no other page furniture.
+		wrapper = parent
+	} else {
+		rowElement := &html.Node{
+			Type: html.ElementNode,
+			Data: "div",
+		}
+
+		if v.CanInterface() {
+			if _, ok := v.Interface().(CustomEncoder); ok {
+				// custom elements can't use the label's "for" attribute, so give them the label's id instead.
+				field.LabelID = LabelID(field.ElementID(key))
+			}
+		}
+
+		field.HelpTextID = HelpTextID(field.ElementID(key))
+		field.ValidationTextID = ValidationTextID(field.ElementID(key))
+
+		BuildLabel(key, rowElement, field, decorator)
+		wrapper = &html.Node{
+			Type: html.ElementNode,
+			Data: "div",
+		}
+
+		rowElement.AppendChild(wrapper)
+		decorator.FieldWrapper(wrapper, field)
+
+		parent.AppendChild(rowElement)
+
+		defer func() {
+			if field.hasResetControl() {
+				wrapper.AppendChild(buildResetControl(key, field, decorator))
+			}
+
+			if len(field.ValidationErrors) > 0 && !field.errorInLabel {
+				BuildValidationText(wrapper, field, decorator)
+			}
+
+			BuildHelpText(wrapper, field, decorator)
+			decorator.Row(rowElement, field)
+		}()
+	}
+
+	if hasRaw {
+		// the submitted value may not be representable by the type of v (e.g. "abc" for a number field), so it is
+		// written into the element as it was submitted.
+		defer setSubmittedValue(wrapper, key, raw)
+	}
+
+	if field.Template() != "" {
+		return buildTemplateField(v, key, field, wrapper)
+	}
+
+	if isNullableSelect(v.Type()) {
+		n := buildNullableSelectField(v, key, field)
+		appendFormElement(wrapper, n, field)
+		decorator.SelectField(n, field)
+		return nil
+	}
+
+	if v.CanInterface() {
+		switch a := v.Interface().(type) {
+		case CustomEncoder:
+			return a.BuildFormElement(key, wrapper, field, decorator)
+		case time.Time:
+			loc, err := field.location()
+
+			if err != nil {
+				return err
+			}
+
+			if a.IsZero() && field.defaultsToNow() {
+				a = time.Now()
+			}
+
+			if loc != nil {
+				a = a.In(loc)
+			}
+
+			n := BuildTimeField(a, key, field)
+			appendFormElement(wrapper, n, field)
+			decorator.NumberField(n, field)
+			return nil
+		case big.Rat:
+			// rationals are rendered as text, as a number input can't hold a fraction such as 1/3.
+			n := BuildStringField(reflect.ValueOf(a.RatString()), key, field)
+			appendFormElement(wrapper, n, field)
+			decorator.TextField(n, field)
+			return nil
+		case Flags:
+			// unselected options are not submitted, so an empty value is always submitted to distinguish a field
+			// with no selected flags from one which is absent from the form.
+			wrapper.AppendChild(buildHiddenInput(key, flagsSentinel))
+
+			n := buildSelectField(flagsSelect{value: flagsValue(v), flags: a.FlagOptions()}, key, field)
+			appendFormElement(wrapper, n, field)
+			decorator.SelectField(n, field)
+			return nil
+		case Select:
+			n := buildSelectField(a, key, field)
+			appendFormElement(wrapper, n, field)
+			decorator.SelectField(n, field)
+			return nil
+		case Enum, EnumOptions:
+			n := buildSelectField(newEnumSelect(a), key, field)
+			appendFormElement(wrapper, n, field)
+			decorator.SelectField(n, field)
+			return nil
+		case RadioList:
+			n := BuildRadioButtons(a, key, field, decorator)
+			wrapper.AppendChild(n)
+			return nil
+		case CheckboxGroup:
+			n := BuildCheckboxGroup(a, key, field, decorator)
+			wrapper.AppendChild(n)
+			return nil
+		}
+
+		if isTextType(v.Type()) {
+			text, err := marshalText(v)
+
+			if err != nil {
+				return err
+			}
+
+			n := BuildStringField(reflect.ValueOf(text), key, field)
+			appendFormElement(wrapper, n, field)
+			decorator.TextField(n, field)
+			return nil
+		}
+	}
+
+	if (field.ReadOnly() || field.Disabled()) && isDisplayStringer(v.Type()) {
+		// fields which can't be edited are rendered with their display value, e.g. "1h30m0s" for a time.Duration.
+		n := BuildStringField(reflect.ValueOf(v.Interface().(fmt.Stringer).String()), key, field)
+		appendFormElement(wrapper, n, field)
+		decorator.TextField(n, field)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float64, reflect.Float32:
+		if _, ok := v.Interface().(BoolNumber); ok {
+			n := buildBoolField(v, key, field)
+			appendFormElement(wrapper, n, field)
+			decorator.CheckboxField(n, field)
+		} else {
+			n := BuildNumberField(v, key, field)
+			appendFormElement(wrapper, n, field)
+			decorator.NumberField(n, field)
+		}
+		return nil
+	case reflect.String:
+		n := BuildStringField(v, key, field)
+		appendFormElement(wrapper, n, field)
+
+		if field.Elem() == "textarea" {
+			decorator.TextareaField(n, field)
+		} else {
+			decorator.TextField(n, field)
+		}
+
+		if datalist, ok := v.Interface().(Datalist); ok && field.Elem() != "textarea" {
+			appendDatalist(wrapper, n, datalist, field.ElementID(key))
+		}
+
+		return nil
+	case reflect.Bool:
+		n := buildBoolField(v, key, field)
+		appendFormElement(wrapper, n, field)
+		decorator.CheckboxField(n, field)
+		return nil
+	case reflect.Map:
+		if !isEditableMap(v.Type()) {
+			return fmt.Errorf("%w: %s (%s)", ErrUnsupportedKind, v.Type().String(), key)
+		}
+
+		n := BuildMapField(v, key, field, decorator)
+		wrapper.AppendChild(n)
+		return nil
+	default:
+		return fmt.Errorf("%w: %s (%s)", ErrUnsupportedKind, v.Kind().String(), key)
+	}
+}
+
+// isCollectionKind determines if k is a slice or map kind, which may be nil.
+func isCollectionKind(k reflect.Kind) bool {
+	return k == reflect.Slice || k == reflect.Map
+}
+
+// isSupportedKind determines if BuildField is able to render values of kind k.
+func isSupportedKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float64, reflect.Float32:
+		return true
+	case reflect.String, reflect.Bool:
+		return true
+	default:
+		return false
+	}
+}
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// isDisplayStringer determines if read only and disabled fields of type t are rendered using fmt.Stringer. Only
+// numbers and strings are, as other types which implement fmt.Stringer (e.g. time.Time) have their own rendering.
+func isDisplayStringer(t reflect.Type) bool {
+	if !t.Implements(stringerType) || t == reflect.TypeOf(BoolNumber(0)) {
+		return false
+	}
+
+	return t.Kind() == reflect.String || (isSupportedKind(t.Kind()) && t.Kind() != reflect.Bool)
+}
+
+// appendFormElement adds attributes which are common to all form elements to n, then appends n to parent.
+func appendFormElement(parent, n *html.Node, field StructField) {
+	if field.hasResetControl() {
+		defaultValue, _ := field.DefaultValue()
+
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "data-default",
+			Val: defaultValue,
+		})
+	}
+
+	if accessKey := field.AccessKey(); accessKey != "" {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "accesskey",
+			Val: accessKey,
+		})
+	}
+
+	if field.ReadOnly() {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "readonly",
+			Val: "readonly",
+		})
+	}
+
+	if field.Disabled() && !field.disabledFieldset {
+		n.Attr = append(n.Attr, html.Attribute{Key: "disabled"})
+	}
+
+	if itemProp := field.ItemProp(); itemProp != "" {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "itemprop",
+			Val: itemProp,
+		})
+	}
+
+	if conditionField, conditionValue, ok := field.RequiredWhen(); ok {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "data-required-when",
+			Val: conditionField,
+		}, html.Attribute{
+			Key: "data-required-when-value",
+			Val: conditionValue,
+		})
+	}
+
+	appendARIAAttributes(n, field)
+
+	for _, attr := range field.validatorAttributes() {
+		// attributes set by struct tags take precedence over those of validators.
+		if !HasAttribute(n, attr.Key) {
+			n.Attr = append(n.Attr, attr)
+		}
+	}
+
+	for _, attr := range field.ExtraAttributes() {
+		removeAttribute(n, attr.Key)
+		n.Attr = append(n.Attr, attr)
+	}
+
+	parent.AppendChild(n)
+
+	if field.ReadOnly() {
+		if mirror := buildReadOnlyMirror(n); mirror != nil {
+			parent.AppendChild(mirror)
+		}
+	}
+}
+
+// readOnlyMirrorSuffix is appended to the name of a read only element to give the name of its hidden mirror.
+const readOnlyMirrorSuffix = ".readonly"
+
+// buildReadOnlyMirror builds a hidden input containing the value of the read only element n, which the HTTPDecoder
+// compares to the submitted value to detect tampering. Only text inputs and textareas can be read only, so nil is
+// returned for any other element.
+func buildReadOnlyMirror(n *html.Node) *html.Node {
+	var value string
+
+	switch {
+	case n.Data == "textarea":
+		if n.FirstChild != nil {
+			value = n.FirstChild.Data
+		}
+	case n.Data == "input":
+		switch getAttribute(n, "type") {
+		case "checkbox", "radio", "hidden":
+			return nil
+		}
+
+		value = getAttribute(n, "value")
+	default:
+		return nil
+	}
+
+	return buildHiddenInput(getAttribute(n, "name")+readOnlyMirrorSuffix, value)
+}
+
+// appendARIAAttributes adds aria-describedby, aria-invalid and aria-required attributes to n, so that assistive
+// technologies can announce the field's help text, validation errors and whether it is required. Each input of a
+// RadioList or CheckboxGroup has the attributes of the field.
+func appendARIAAttributes(n *html.Node, field StructField) {
+	var describedBy []string
+
+	if field.ValidationTextID != "" && len(field.ValidationErrors) > 0 {
+		describedBy = append(describedBy, field.ValidationTextID)
+	}
+
+	if helpText, _ := field.helpText(); field.HelpTextID != "" && helpText != "" {
+		describedBy = append(describedBy, field.HelpTextID)
+	}
+
+	if len(describedBy) > 0 {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "aria-describedby",
+			Val: strings.Join(describedBy, " "),
+		})
+	}
+
+	if len(field.ValidationErrors) > 0 {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "aria-invalid",
+			Val: "true",
+		})
+	}
+
+	if field.Required() {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "aria-required",
+			Val: "true",
+		})
+	}
+}
+
+// validationErrorValue returns the most recent value which failed validation for a field, converted
+// to the type of v. If the value cannot be converted, v is returned unchanged.
+func validationErrorValue(v reflect.Value, validationErrors []ValidationError) reflect.Value {
+	value := validationErrors[len(validationErrors)-1].Value
+
+	if value == nil {
+		return v
+	}
+
+	errorValue := reflect.ValueOf(value)
+
+	if !errorValue.Type().ConvertibleTo(v.Type()) {
+		return v
+	}
+
+	if v.Kind() == reflect.String && errorValue.Kind() != reflect.String {
+		// numeric values are convertible to strings, but are treated as runes by reflect.
+		return v
+	}
+
+	return errorValue.Convert(v.Type())
+}
+
+// rawValidationErrorValue returns the most recent value which failed validation for a field, if it is the raw value
+// which was submitted, i.e. a string or a single element []string.
+func rawValidationErrorValue(validationErrors []ValidationError) (string, bool) {
+	if len(validationErrors) == 0 {
+		return "", false
+	}
+
+	switch value := validationErrors[len(validationErrors)-1].Value.(type) {
+	case string:
+		return value, true
+	case []string:
+		if len(value) == 1 {
+			return value[0], true
+		}
+	}
+
+	return "", false
+}
+
+// setSubmittedValue sets the value of the text input or textarea named name within n to value. Checkboxes, radio
+// buttons, hidden inputs and selects are left unchanged, as their values are not entered by the user.
+func setSubmittedValue(n *html.Node, name, value string) bool {
+	if n.Type == html.ElementNode && getAttribute(n, "name") == name {
+		switch n.Data {
+		case "input":
+			switch getAttribute(n, "type") {
+			case "checkbox", "radio", "hidden":
+			default:
+				setAttribute(n, "value", value)
+				return true
+			}
+		case "textarea":
+			for n.FirstChild != nil {
+				n.RemoveChild(n.FirstChild)
+			}
+
+			n.AppendChild(&html.Node{
+				Type: html.TextNode,
+				Data: value,
+			})
+
+			return true
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if setSubmittedValue(c, name, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isEditableMap determines if a map of type t can be rendered with an input for each entry.
+func isEditableMap(t reflect.Type) bool {
+	return t.Key().Kind() == reflect.String && isSupportedKind(t.Elem().Kind())
+}
+
+// BuildMapField builds a labelled input for each entry of the map v, in key order. Each input is named key[mapKey],
+// which is decoded back into the map by the HTTPDecoder. Maps are only rendered this way if the field has the
+// elem:"map" struct tag, otherwise they are rendered as JSON in a <textarea>.
+func BuildMapField(v reflect.Value, key string, field StructField, decorator Decorator) *html.Node {
+	div := &html.Node{
+		Type: html.ElementNode,
+		Data: "div",
+		Attr: []html.Attribute{
+			{
+				Key: "id",
+				Val: field.ElementID(key),
+			},
+		},
+	}
+
+	mapKeys := v.MapKeys()
+
+	sort.Slice(mapKeys, func(i, j int) bool {
+		return mapKeys[i].String() < mapKeys[j].String()
+	})
+
+	for _, mapKey := range mapKeys {
+		name := mapEntryName(key, mapKey.String())
+		entry := v.MapIndex(mapKey)
+
+		row := &html.Node{
+			Type: html.ElementNode,
+			Data: "div",
+		}
+
+		label := &html.Node{
+			Type: html.ElementNode,
+			Data: "label",
+			Attr: []html.Attribute{
+				{
+					Key: "for",
+					Val: field.ElementID(name),
+				},
+			},
+		}
+
+		label.AppendChild(&html.Node{
+			Type: html.TextNode,
+			Data: mapKey.String(),
+		})
+
+		row.AppendChild(label)
+		decorator.Label(label, field)
+
+		switch entry.Kind() {
+		case reflect.Bool:
+			// unchecked checkboxes are not submitted, so each entry has a hidden fallback value, which keeps the
+			// entry in the map as false unless its checkbox is checked.
+			row.AppendChild(buildHiddenInput(name, "false"))
+
+			n := buildBoolField(entry, name, field)
+			row.AppendChild(n)
+			decorator.CheckboxField(n, field)
+		case reflect.String:
+			n := BuildStringField(entry, name, field)
+			row.AppendChild(n)
+			decorator.TextField(n, field)
+		default:
+			n := BuildNumberField(entry, name, field)
+			row.AppendChild(n)
+			decorator.NumberField(n, field)
+		}
+
+		div.AppendChild(row)
+	}
+
+	return div
+}
+
+// precisionStep returns the step of a number input with the given number of decimal places, e.g. 0.01 for 2.
+func precisionStep(precision int) string {
+	if precision == 0 {
+		return "1"
+	}
+
+	return "0." + strings.Repeat("0", precision-1) + "1"
+}
+
+const timeFormat = "2006-01-02T15:04"
+
+// timeLayouts are the time layouts used by each of the supported time input types.
+var timeLayouts = map[string]string{
+	"datetime-local": timeFormat,
+	"date":           "2006-01-02",
+	"time":           "15:04",
+	"month":          "2006-01",
+}
+
+// timeLayout returns the layout used for the given time format (see StructField.TimeFormat).
+// Unknown formats use the datetime-local layout.
+func timeLayout(format string) string {
+	if layout, ok := timeLayouts[format]; ok {
+		return layout
+	}
+
+	return timeFormat
+}
+
+// formatTimeValue formats t as the value of a time input of the given format. Weeks are formatted as ISO 8601
+// weeks (e.g. 2020-W05), which can't be represented by a time layout.
+func formatTimeValue(t time.Time, format string) string {
+	if format == "week" {
+		year, week := t.ISOWeek()
+
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	}
+
+	return t.Format(timeLayout(format))
+}
+
+// parseTimeValue parses the value of a time input of the given format in loc. Weeks are parsed as the
+// Monday at the start of the ISO 8601 week.
+func parseTimeValue(format, value string, loc *time.Location) (time.Time, error) {
+	if format == "week" {
+		return parseISOWeek(value, loc)
+	}
+
+	return time.ParseInLocation(timeLayout(format), value, loc)
+}
+
+// parseISOWeek parses an ISO 8601 week of the form 2006-W01, returning the Monday at the start of the week.
+func parseISOWeek(value string, loc *time.Location) (time.Time, error) {
+	if len(value) != 8 || value[4:6] != "-W" {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidWeek, value)
 	}
 
-	switch v.Kind() {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float64, reflect.Float32:
-		if _, ok := v.Interface().(BoolNumber); ok {
-			n := BuildBoolField(v, key)
-			wrapper.AppendChild(n)
-			decorator.CheckboxField(n, field)
-		} else {
-			n := BuildNumberField(v, key, field)
-			wrapper.AppendChild(n)
-			decorator.NumberField(n, field)
-		}
-		return nil
-	case reflect.String:
-		n := BuildStringField(v, key, field)
-		wrapper.AppendChild(n)
+	year, err := strconv.Atoi(value[:4])
 
-		if field.Elem() == "textarea" {
-			decorator.TextareaField(n, field)
-		} else {
-			decorator.TextField(n, field)
-		}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidWeek, value)
+	}
 
-		return nil
-	case reflect.Bool:
-		n := BuildBoolField(v, key)
-		wrapper.AppendChild(n)
-		decorator.CheckboxField(n, field)
-		return nil
-	default:
-		panic("formulate: unknown element kind: " + v.Kind().String())
+	week, err := strconv.Atoi(value[6:])
+
+	if err != nil || week < 1 || week > isoWeeksInYear(year) {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidWeek, value)
 	}
+
+	// 4th January is always in the first ISO week of its year.
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+
+	return monday.AddDate(0, 0, (week-1)*7), nil
 }
 
-const timeFormat = "2006-01-02T15:04"
+// isoWeeksInYear returns the number of ISO 8601 weeks in year, either 52 or 53.
+func isoWeeksInYear(year int) int {
+	// 28th December is always in the last ISO week of its year.
+	_, week := time.Date(year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek()
+
+	return week
+}
 
 func BuildTimeField(t time.Time, key string, field StructField) *html.Node {
+	return buildTimeInput(t, key, field, field.TimeFormat())
+}
+
+// buildTimeInput builds a time input of the given format (see StructField.TimeFormat).
+func buildTimeInput(t time.Time, key string, field StructField, format string) *html.Node {
+	typ := field.InputType(format)
+	value := formatTimeValue(t, format)
+
+	if typ == "text" && field.formatsForLocale() {
+		value = field.locale.FormatTime(t)
+	}
+
 	n := &html.Node{
 		Type: html.ElementNode,
 		Data: "input",
 		Attr: []html.Attribute{
 			{
 				Key: "type",
-				Val: "datetime-local", // @TODO consider replacing use of datetime-local with a time and date input
+				Val: typ,
 			},
 			{
 				Key: "name",
@@ -357,11 +2006,11 @@ func BuildTimeField(t time.Time, key string, field StructField) *html.Node {
 			},
 			{
 				Key: "id",
-				Val: key,
+				Val: field.ElementID(key),
 			},
 			{
 				Key: "value",
-				Val: t.Format(timeFormat),
+				Val: value,
 			},
 		},
 	}
@@ -380,17 +2029,38 @@ func BuildTimeField(t time.Time, key string, field StructField) *html.Node {
 		})
 	}
 
+	if autocomplete := field.Autocomplete(); autocomplete != "" {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "autocomplete",
+			Val: autocomplete,
+		})
+	}
+
 	return n
 }
 
 func BuildNumberField(v reflect.Value, key string, field StructField) *html.Node {
+	typ := field.InputType("number")
+	value := toString(v.Interface())
+
+	isFloat := v.Kind() == reflect.Float64 || v.Kind() == reflect.Float32
+	precision, hasPrecision := field.Precision()
+
+	if isFloat && hasPrecision {
+		value = strconv.FormatFloat(v.Float(), 'f', precision, 64)
+	}
+
+	if typ == "text" && field.formatsForLocale() {
+		value = field.locale.FormatNumber(v.Interface())
+	}
+
 	n := &html.Node{
 		Type: html.ElementNode,
 		Data: "input",
 		Attr: []html.Attribute{
 			{
 				Key: "type",
-				Val: "number",
+				Val: typ,
 			},
 			{
 				Key: "name",
@@ -398,11 +2068,11 @@ func BuildNumberField(v reflect.Value, key string, field StructField) *html.Node
 			},
 			{
 				Key: "id",
-				Val: key,
+				Val: field.ElementID(key),
 			},
 			{
 				Key: "value",
-				Val: toString(v.Interface()),
+				Val: value,
 			},
 		},
 	}
@@ -426,13 +2096,32 @@ func BuildNumberField(v reflect.Value, key string, field StructField) *html.Node
 			Key: "step",
 			Val: field.Step(),
 		})
-	} else if v.Kind() == reflect.Float64 || v.Kind() == reflect.Float32 {
+	} else if isFloat && hasPrecision {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "step",
+			Val: precisionStep(precision),
+		})
+	} else if isFloat {
 		n.Attr = append(n.Attr, html.Attribute{
 			Key: "step",
 			Val: "any",
 		})
 	}
 
+	if inputMode := field.InputMode(); inputMode != "" {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "inputmode",
+			Val: inputMode,
+		})
+	}
+
+	if autocomplete := field.Autocomplete(); autocomplete != "" {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "autocomplete",
+			Val: autocomplete,
+		})
+	}
+
 	return n
 }
 
@@ -450,7 +2139,7 @@ func BuildStringField(v reflect.Value, key string, field StructField) *html.Node
 				},
 				{
 					Key: "id",
-					Val: key,
+					Val: field.ElementID(key),
 				},
 			},
 		}
@@ -459,6 +2148,20 @@ func BuildStringField(v reflect.Value, key string, field StructField) *html.Node
 			Type: html.TextNode,
 			Data: v.String(),
 		})
+
+		if rows := field.Rows(); rows != "" {
+			n.Attr = append(n.Attr, html.Attribute{
+				Key: "rows",
+				Val: rows,
+			})
+		}
+
+		if cols := field.Cols(); cols != "" {
+			n.Attr = append(n.Attr, html.Attribute{
+				Key: "cols",
+				Val: cols,
+			})
+		}
 	} else {
 		typField := func() string {
 			if v.CanInterface() {
@@ -493,7 +2196,7 @@ func BuildStringField(v reflect.Value, key string, field StructField) *html.Node
 				},
 				{
 					Key: "id",
-					Val: key,
+					Val: field.ElementID(key),
 				},
 				{
 					Key: "value",
@@ -508,6 +2211,13 @@ func BuildStringField(v reflect.Value, key string, field StructField) *html.Node
 				Val: pattern,
 			})
 		}
+
+		if accept := field.Accept(); accept != "" && getAttribute(n, "type") == "file" {
+			n.Attr = append(n.Attr, html.Attribute{
+				Key: "accept",
+				Val: accept,
+			})
+		}
 	}
 
 	if placeholder := field.Placeholder(); placeholder != "" {
@@ -517,6 +2227,13 @@ func BuildStringField(v reflect.Value, key string, field StructField) *html.Node
 		})
 	}
 
+	if inputMode := field.InputMode(); inputMode != "" {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "inputmode",
+			Val: inputMode,
+		})
+	}
+
 	if field.Required() {
 		n.Attr = append(n.Attr, html.Attribute{
 			Key: "required",
@@ -538,10 +2255,73 @@ func BuildStringField(v reflect.Value, key string, field StructField) *html.Node
 		})
 	}
 
+	if autocomplete := field.Autocomplete(); autocomplete != "" {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "autocomplete",
+			Val: autocomplete,
+		})
+	}
+
+	return n
+}
+
+// BuildDatalist builds a <datalist> of the suggested values in d.
+func BuildDatalist(d Datalist, key string) *html.Node {
+	n := &html.Node{
+		Type: html.ElementNode,
+		Data: "datalist",
+		Attr: []html.Attribute{
+			{
+				Key: "id",
+				Val: datalistID(key),
+			},
+		},
+	}
+
+	for _, opt := range d.DatalistOptions() {
+		o := &html.Node{
+			Type: html.ElementNode,
+			Data: "option",
+			Attr: []html.Attribute{
+				{
+					Key: "value",
+					Val: toString(opt.Value),
+				},
+			},
+		}
+
+		if opt.Label != "" && opt.Label != toString(opt.Value) {
+			o.AppendChild(&html.Node{
+				Type: html.TextNode,
+				Data: opt.Label,
+			})
+		}
+
+		n.AppendChild(o)
+	}
+
 	return n
 }
 
+func datalistID(key string) string {
+	return key + "-datalist"
+}
+
+// appendDatalist builds the <datalist> for the input n, which has the given id, and appends it to parent.
+func appendDatalist(parent, n *html.Node, d Datalist, id string) {
+	n.Attr = append(n.Attr, html.Attribute{
+		Key: "list",
+		Val: datalistID(id),
+	})
+
+	parent.AppendChild(BuildDatalist(d, id))
+}
+
 func BuildBoolField(v reflect.Value, key string) *html.Node {
+	return buildBoolField(v, key, StructField{})
+}
+
+func buildBoolField(v reflect.Value, key string, field StructField) *html.Node {
 	n := &html.Node{
 		Type: html.ElementNode,
 		Data: "input",
@@ -556,7 +2336,7 @@ func BuildBoolField(v reflect.Value, key string) *html.Node {
 			},
 			{
 				Key: "id",
-				Val: key,
+				Val: field.ElementID(key),
 			},
 		},
 	}
@@ -578,7 +2358,67 @@ func BuildBoolField(v reflect.Value, key string) *html.Node {
 	return n
 }
 
+// NoneOptionLabel is the label of the empty option which is added to nullable Select fields.
+var NoneOptionLabel = "— none —"
+
+// BuildNullableSelectField builds a <select> for v, a pointer to a type which implements Select (e.g. *Status).
+// An empty option labelled NoneOptionLabel is added before the Select's options, and is selected if v is nil.
+// The HTTPDecoder decodes the empty option as a nil pointer.
+func BuildNullableSelectField(v reflect.Value, key string) *html.Node {
+	return buildNullableSelectField(v, key, StructField{})
+}
+
+func buildNullableSelectField(v reflect.Value, key string, field StructField) *html.Node {
+	isNil := v.IsNil()
+
+	if isNil {
+		v = reflect.New(v.Type().Elem())
+	}
+
+	sel := buildSelectField(v.Elem().Interface().(Select), key, field)
+
+	none := &html.Node{
+		Type: html.ElementNode,
+		Data: "option",
+		Attr: []html.Attribute{
+			{
+				Key: "value",
+				Val: "",
+			},
+		},
+	}
+
+	if isNil {
+		// the zero value may match one of the options, but nothing has been chosen.
+		for o := sel.FirstChild; o != nil; o = o.NextSibling {
+			removeAttribute(o, "selected")
+
+			for c := o.FirstChild; c != nil; c = c.NextSibling {
+				removeAttribute(c, "selected")
+			}
+		}
+
+		none.Attr = append(none.Attr, html.Attribute{Key: "selected"})
+	}
+
+	none.AppendChild(&html.Node{
+		Type: html.TextNode,
+		Data: NoneOptionLabel,
+	})
+
+	sel.InsertBefore(none, sel.FirstChild)
+
+	return sel
+}
+
+// BuildSelectField builds a <select> for s. Options with a Group are rendered inside an <optgroup>. Ungrouped options
+// are rendered first, followed by each optgroup in the order that its group first appears in s.SelectOptions(), so
+// the output is stable for a given set of options.
 func BuildSelectField(s Select, key string) *html.Node {
+	return buildSelectField(s, key, StructField{})
+}
+
+func buildSelectField(s Select, key string, field StructField) *html.Node {
 	sel := &html.Node{
 		Type: html.ElementNode,
 		Data: "select",
@@ -589,7 +2429,7 @@ func BuildSelectField(s Select, key string) *html.Node {
 			},
 			{
 				Key: "id",
-				Val: key,
+				Val: field.ElementID(key),
 			},
 		},
 	}
@@ -600,106 +2440,204 @@ func BuildSelectField(s Select, key string) *html.Node {
 		})
 	}
 
-	optGroups := make(map[string]*html.Node)
+	var groups []*html.Node
 
-	selectOptions := s.SelectOptions()
+	groupsByLabel := make(map[string]*html.Node)
 
-	for _, opt := range selectOptions {
-		if opt.Group == nil {
-			continue
+	for _, opt := range s.SelectOptions() {
+		o := &html.Node{
+			Type: html.ElementNode,
+			Data: "option",
+			Attr: []html.Attribute{
+				{
+					Key: "value",
+					Val: toString(opt.Value),
+				},
+			},
+		}
+
+		if opt.Disabled {
+			o.Attr = append(o.Attr, html.Attribute{Key: "disabled"})
+		}
+
+		if opt.Title != "" {
+			o.Attr = append(o.Attr, html.Attribute{Key: "title", Val: opt.Title})
+		}
+
+		checked := false
+
+		if opt.Checked == nil {
+			v := reflect.ValueOf(s)
+			optValue := toString(opt.Value)
+
+			switch v.Kind() {
+			case reflect.Slice, reflect.Array:
+				for i := 0; i < v.Len(); i++ {
+					val := v.Index(i)
+
+					if val.CanInterface() {
+						if toString(val.Interface()) == optValue {
+							checked = true
+							break
+						}
+					}
+				}
+			default:
+				checked = toString(s) == optValue
+			}
+		} else {
+			checked = bool(*opt.Checked)
+		}
+
+		if checked {
+			o.Attr = append(o.Attr, html.Attribute{Key: "selected"})
 		}
 
-		if _, ok := optGroups[*opt.Group]; ok {
+		o.Attr = append(o.Attr, opt.Attr...)
+
+		o.AppendChild(&html.Node{
+			Type: html.TextNode,
+			Data: field.optionLabel(opt),
+		})
+
+		if opt.Group == nil {
+			sel.AppendChild(o)
 			continue
 		}
 
-		optGroups[*opt.Group] = &html.Node{
-			Type: html.ElementNode,
-			Data: "optgroup",
-			Attr: []html.Attribute{
-				{
-					Key: "label",
-					Val: *opt.Group,
+		group, ok := groupsByLabel[*opt.Group]
+
+		if !ok {
+			group = &html.Node{
+				Type: html.ElementNode,
+				Data: "optgroup",
+				Attr: []html.Attribute{
+					{
+						Key: "label",
+						Val: *opt.Group,
+					},
 				},
+			}
+
+			groupsByLabel[*opt.Group] = group
+			groups = append(groups, group)
+		}
+
+		if opt.GroupDisabled && !HasAttribute(group, "disabled") {
+			group.Attr = append(group.Attr, html.Attribute{Key: "disabled"})
+		}
+
+		group.AppendChild(o)
+	}
+
+	for _, group := range groups {
+		sel.AppendChild(group)
+	}
+
+	return sel
+}
+
+// checkboxGroupSentinel is the value of the hidden input which is submitted with every CheckboxGroup.
+const checkboxGroupSentinel = ""
+
+// BuildCheckboxGroup builds a labelled <input type="checkbox"> named key for each of the options of c.
+// If an option's Checked condition is not set, it is checked if its value is one of the values in c.
+func BuildCheckboxGroup(c CheckboxGroup, key string, field StructField, decorator Decorator) *html.Node {
+	div := &html.Node{
+		Type: html.ElementNode,
+		Data: "div",
+		Attr: []html.Attribute{
+			{
+				Key: "id",
+				Val: field.ElementID(key),
 			},
+		},
+	}
+
+	values := make(map[string]bool)
+	v := reflect.ValueOf(c)
+
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		for i := 0; i < v.Len(); i++ {
+			if val := v.Index(i); val.CanInterface() {
+				values[toString(val.Interface())] = true
+			}
 		}
 	}
 
-	for _, opt := range selectOptions {
-		o := &html.Node{
+	// unchecked checkboxes are not submitted, so an empty value is always submitted to distinguish a group with no
+	// checked options from one which is absent from the form.
+	div.AppendChild(buildHiddenInput(key, checkboxGroupSentinel))
+
+	for i, opt := range c.CheckboxOptions() {
+		id := fmt.Sprintf("%s%d", field.ElementID(key), i)
+
+		checkbox := &html.Node{
 			Type: html.ElementNode,
-			Data: "option",
+			Data: "input",
 			Attr: []html.Attribute{
+				{
+					Key: "type",
+					Val: "checkbox",
+				},
 				{
 					Key: "value",
 					Val: toString(opt.Value),
 				},
+				{
+					Key: "id",
+					Val: id,
+				},
+				{
+					Key: "name",
+					Val: key,
+				},
 			},
 		}
 
 		if opt.Disabled {
-			o.Attr = append(o.Attr, html.Attribute{Key: "disabled"})
+			checkbox.Attr = append(checkbox.Attr, html.Attribute{Key: "disabled"})
 		}
 
-		checked := false
-
-		if opt.Checked == nil {
-			v := reflect.ValueOf(s)
-			optValue := toString(opt.Value)
-
-			switch v.Kind() {
-			case reflect.Slice, reflect.Array:
-				for i := 0; i < v.Len(); i++ {
-					val := v.Index(i)
+		checkbox.Attr = append(checkbox.Attr, opt.Attr...)
+		appendARIAAttributes(checkbox, field)
 
-					if val.CanInterface() {
-						if toString(val.Interface()) == optValue {
-							checked = true
-							break
-						}
-					}
-				}
-			default:
-				checked = toString(s) == optValue
-			}
+		checked := false
+
+		if opt.Checked == nil {
+			checked = values[toString(opt.Value)]
 		} else {
 			checked = bool(*opt.Checked)
 		}
 
 		if checked {
-			o.Attr = append(o.Attr, html.Attribute{Key: "selected"})
+			checkbox.Attr = append(checkbox.Attr, html.Attribute{Key: "checked"})
 		}
 
-		o.Attr = append(o.Attr, opt.Attr...)
+		label := &html.Node{
+			Type: html.ElementNode,
+			Data: "label",
+			Attr: []html.Attribute{
+				{
+					Key: "for",
+					Val: id,
+				},
+			},
+		}
 
-		o.AppendChild(&html.Node{
+		label.AppendChild(&html.Node{
 			Type: html.TextNode,
-			Data: opt.Label,
+			Data: field.optionLabel(opt),
 		})
 
-		if opt.Group != nil {
-			optGroups[*opt.Group].AppendChild(o)
-		} else {
-			sel.AppendChild(o)
-		}
-	}
-
-	addedOptGroups := make(map[string]bool)
-
-	for _, opt := range selectOptions {
-		if opt.Group == nil {
-			continue
-		}
-
-		if _, ok := addedOptGroups[*opt.Group]; ok {
-			continue
-		}
+		div.AppendChild(label)
+		div.AppendChild(checkbox)
 
-		sel.AppendChild(optGroups[*opt.Group])
-		addedOptGroups[*opt.Group] = true
+		decorator.Label(label, field)
+		decorator.CheckboxField(checkbox, field)
 	}
 
-	return sel
+	return div
 }
 
 func BuildRadioButtons(r RadioList, key string, field StructField, decorator Decorator) *html.Node {
@@ -709,13 +2647,13 @@ func BuildRadioButtons(r RadioList, key string, field StructField, decorator Dec
 		Attr: []html.Attribute{
 			{
 				Key: "id",
-				Val: key,
+				Val: field.ElementID(key),
 			},
 		},
 	}
 
 	for i, opt := range r.RadioOptions() {
-		id := fmt.Sprintf("%s%d", key, i)
+		id := fmt.Sprintf("%s%d", field.ElementID(key), i)
 
 		radio := &html.Node{
 			Type: html.ElementNode,
@@ -745,6 +2683,7 @@ func BuildRadioButtons(r RadioList, key string, field StructField, decorator Dec
 		}
 
 		radio.Attr = append(radio.Attr, opt.Attr...)
+		appendARIAAttributes(radio, field)
 
 		checked := false
 
@@ -771,7 +2710,7 @@ func BuildRadioButtons(r RadioList, key string, field StructField, decorator Dec
 
 		label.AppendChild(&html.Node{
 			Type: html.TextNode,
-			Data: opt.Label,
+			Data: field.optionLabel(opt),
 		})
 
 		div.AppendChild(label)
@@ -786,7 +2725,10 @@ func BuildRadioButtons(r RadioList, key string, field StructField, decorator Dec
 
 const fieldSeparator = "."
 
-// FormElementName returns the name of the form element within the form, removing the package path and base struct name.
+// FormElementName returns the name of the form element within the form, removing the package path and base struct name,
+// e.g. "formulate.Person.Address.HouseNumber" becomes "Address.HouseNumber". The keys passed to CustomEncoders and
+// CustomDecoders always begin with a root key of exactly two segments (see rootKey), so the names of top-level and
+// nested fields are the same when encoding and decoding.
 func FormElementName(key string) string {
 	keySplit := strings.Split(key, fieldSeparator)
 
@@ -797,6 +2739,40 @@ func FormElementName(key string) string {
 	return key
 }
 
+// rootKey returns the key of the struct type t which is encoded or decoded, e.g. "formulate.Person". The keys of
+// fields begin with the root key, which FormElementName removes (unless full element names are enabled), so the root
+// key always has exactly two segments, even if the name of t contains dots, e.g. anonymous structs with time.Time
+// fields, or generic types.
+func rootKey(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Name() == "" {
+		return "anonymous" + fieldSeparator + "struct"
+	}
+
+	pkg := strings.ReplaceAll(path.Base(t.PkgPath()), fieldSeparator, "_")
+	name := strings.ReplaceAll(t.Name(), fieldSeparator, "_")
+
+	return pkg + fieldSeparator + name
+}
+
+// LabelID returns the id of the <label> for the form element named key.
+func LabelID(key string) string {
+	return key + "-label"
+}
+
+// HelpTextID returns the id of the help text for the form element named key.
+func HelpTextID(key string) string {
+	return key + "-help"
+}
+
+// ValidationTextID returns the id of the validation text for the form element named key.
+func ValidationTextID(key string) string {
+	return key + "-errors"
+}
+
 func BuildLabel(label string, parent *html.Node, field StructField, decorator Decorator) {
 	n := &html.Node{
 		Type: html.ElementNode,
@@ -804,33 +2780,119 @@ func BuildLabel(label string, parent *html.Node, field StructField, decorator De
 		Attr: []html.Attribute{
 			{
 				Key: "for",
-				Val: label,
+				Val: field.ElementID(label),
 			},
 		},
 	}
 
+	if field.LabelID != "" {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "id",
+			Val: field.LabelID,
+		})
+	}
+
+	name := field.translate("", field.GetName())
+
 	n.AppendChild(&html.Node{
 		Type: html.TextNode,
-		Data: field.GetName(),
+		Data: name,
 	})
 
+	if name != "" && field.labelSuffix != "" {
+		n.AppendChild(&html.Node{
+			Type: html.TextNode,
+			Data: field.labelSuffix,
+		})
+	}
+
+	BuildHint(n, field, decorator)
+
+	if field.errorInLabel && len(field.ValidationErrors) > 0 {
+		BuildLabelError(n, field, decorator)
+	}
+
 	parent.AppendChild(n)
 	decorator.Label(n, field)
 }
 
-func BuildHelpText(parent *html.Node, field StructField, decorator Decorator) {
-	helpText := field.GetHelpText()
+// BuildLabelError builds a <span> containing the first validation error of the field, within its <label>.
+// See HTMLEncoder.SetErrorInLabel.
+func BuildLabelError(parent *html.Node, field StructField, decorator Decorator) {
+	n := &html.Node{
+		Type: html.ElementNode,
+		Data: "span",
+	}
+
+	if field.ValidationTextID != "" {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "id",
+			Val: field.ValidationTextID,
+		})
+	}
+
+	n.AppendChild(&html.Node{
+		Type: html.TextNode,
+		Data: field.ValidationErrors[0].Error,
+	})
+
+	parent.AppendChild(n)
+
+	if decorator, ok := decorator.(LabelErrorDecorator); ok {
+		decorator.LabelError(n, field)
+	}
+}
+
+// BuildHint builds a <small> element containing the field's hint, if it has one. Hints are brief, and are displayed
+// within the field's <label>, whereas help text is displayed below the input.
+func BuildHint(parent *html.Node, field StructField, decorator Decorator) {
+	hint := field.translate("hint", field.GetHint())
+
+	if hint == "" {
+		return
+	}
 
 	n := &html.Node{
 		Type: html.ElementNode,
-		Data: "div",
+		Data: "small",
 	}
 
 	n.AppendChild(&html.Node{
 		Type: html.TextNode,
-		Data: helpText,
+		Data: hint,
 	})
 
+	parent.AppendChild(n)
+
+	if decorator, ok := decorator.(HintDecorator); ok {
+		decorator.Hint(n, field)
+	}
+}
+
+// BuildHelpText builds a <div> containing the field's help text. If the field has a helphtml tag, its help text is
+// rendered as HTML. HTML help text which can't be parsed is rendered as plain text.
+func BuildHelpText(parent *html.Node, field StructField, decorator Decorator) {
+	helpText, isHTML := field.helpText()
+
+	n := &html.Node{
+		Type: html.ElementNode,
+		Data: "div",
+	}
+
+	if field.HelpTextID != "" && helpText != "" {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "id",
+			Val: field.HelpTextID,
+		})
+	}
+
+	if !isHTML || RenderHTMLToNode(template.HTML(helpText), n) != nil {
+		n.AppendChild(&html.Node{
+			Type: html.TextNode,
+			Data: helpText,
+		})
+	}
+
 	parent.AppendChild(n)
 	decorator.HelpText(n, field)
 }
@@ -843,6 +2905,13 @@ func BuildValidationText(parent *html.Node, field StructField, decorator Decorat
 		Data: "div",
 	}
 
+	if field.ValidationTextID != "" {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "id",
+			Val: field.ValidationTextID,
+		})
+	}
+
 	var errs []string
 
 	for _, err := range validationErrors {
@@ -880,8 +2949,275 @@ func toString(i interface{}) string {
 var (
 	// ErrInvalidCSRFToken indicates that the csrf middleware has not been loaded in the handler chain.
 	ErrInvalidCSRFToken = errors.New("formulate: invalid CSRF token")
+
+	// ErrUnsupportedKind indicates that a field is of a kind which formulate cannot render, e.g. a func or chan.
+	// See HTMLEncoder.SetSkipUnsupportedFields.
+	ErrUnsupportedKind = errors.New("formulate: unsupported element kind")
+
+	// ErrInvalidAccessKey indicates that a field's accesskey tag is not a single character. It is only
+	// returned if HTMLEncoder.SetStrictAccessKeys is enabled.
+	ErrInvalidAccessKey = errors.New("formulate: accesskey must be a single character")
+
+	// ErrInvalidComputedField indicates that the method named in a field's computed or display tag does not exist,
+	// or does not take zero arguments and return a single value.
+	ErrInvalidComputedField = errors.New("formulate: invalid computed field method")
+
+	// ErrInvalidWeek indicates that the value of a week input is not a valid ISO 8601 week, e.g. 2020-W05.
+	ErrInvalidWeek = errors.New("formulate: invalid week")
+
+	// ErrInvalidRat indicates that the value of a big.Rat field is neither a fraction (e.g. 1/3) nor a decimal (e.g. 0.25).
+	ErrInvalidRat = errors.New("formulate: invalid rational number")
+
+	// ErrInvalidParsedValue indicates that a ValueParser registered with HTTPDecoder.RegisterParser returned a value
+	// which can't be assigned to the field being decoded.
+	ErrInvalidParsedValue = errors.New("formulate: parsed value is not assignable to field")
+
+	// ErrInvalidOptional indicates that a type which implements OptionalValue does not have GetValue and SetValue
+	// methods of the required signatures.
+	ErrInvalidOptional = errors.New("formulate: invalid optional value type")
+
+	// ErrInvalidFlag indicates that a value submitted for a Flags field is not one of its flags.
+	ErrInvalidFlag = errors.New("formulate: invalid flag")
+
+	// ErrNilFieldValue indicates that EncodeField was passed a nil value, which has no type to render.
+	ErrNilFieldValue = errors.New("formulate: EncodeField requires a non-nil value")
+
+	// ErrMissingFieldKey indicates that EncodeField was passed a struct tag without a key tag.
+	ErrMissingFieldKey = errors.New("formulate: EncodeField requires a key tag")
+
+	// ErrInvalidRating indicates that a value submitted for a Rating field is not a non-negative integer.
+	ErrInvalidRating = errors.New("formulate: invalid rating")
+
+	// ErrUnknownTag indicates that a field has a struct tag which formulate does not recognise. It is only
+	// returned if HTMLEncoder.SetValidateTags is enabled.
+	ErrUnknownTag = errors.New("formulate: unknown struct tag")
+
+	// ErrHoneypotFilled indicates that the honeypot input of a form was filled in, so the form was probably
+	// submitted by a bot. See HTTPDecoder.SetHoneypot.
+	ErrHoneypotFilled = errors.New("formulate: honeypot field was filled in")
+
+	// ErrUnknownTemplate indicates that the template named by a field's template tag was not found in the
+	// templates passed to HTMLEncoder.SetTemplates.
+	ErrUnknownTemplate = errors.New("formulate: unknown template")
 )
 
+// buildSubmitButton builds a <button type="submit"> with the given label.
+func buildSubmitButton(label string) *html.Node {
+	n := &html.Node{
+		Type: html.ElementNode,
+		Data: "button",
+		Attr: []html.Attribute{
+			{
+				Key: "type",
+				Val: "submit",
+			},
+		},
+	}
+
+	n.AppendChild(&html.Node{
+		Type: html.TextNode,
+		Data: label,
+	})
+
+	return n
+}
+
+// buildFieldGroup builds the <div> which wraps the rows of the fields in the named group.
+func (h *HTMLEncoder) buildFieldGroup(group string) *html.Node {
+	n := &html.Node{
+		Type: html.ElementNode,
+		Data: "div",
+	}
+
+	if decorator, ok := h.decorator.(FieldGroupDecorator); ok {
+		decorator.FieldGroup(n, group)
+	}
+
+	return n
+}
+
+// hideFormControls replaces the children of n with an <input type="hidden"> for each name and value which the
+// form controls within n would submit, in document order.
+func hideFormControls(n *html.Node) {
+	var hidden []*html.Node
+
+	var walk func(c *html.Node)
+
+	walk = func(c *html.Node) {
+		if c.Type == html.ElementNode {
+			if c.Data == "template" || HasAttribute(c, "disabled") {
+				// templates (e.g. of repeatable groups) and disabled elements are not submitted.
+				return
+			}
+
+			if name := getAttribute(c, "name"); name != "" {
+				for _, value := range submittedValues(c) {
+					hidden = append(hidden, buildHiddenInput(name, value))
+				}
+			}
+		}
+
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+
+	walk(n)
+
+	for n.FirstChild != nil {
+		n.RemoveChild(n.FirstChild)
+	}
+
+	for _, input := range hidden {
+		n.AppendChild(input)
+	}
+}
+
+// submittedValues returns the values which a browser would submit for the form control n.
+func submittedValues(n *html.Node) []string {
+	switch n.Data {
+	case "input":
+		switch strings.ToLower(getAttribute(n, "type")) {
+		case "submit", "button", "reset", "image", "file":
+			return nil
+		case "checkbox", "radio":
+			if !HasAttribute(n, "checked") {
+				return nil
+			}
+
+			if !HasAttribute(n, "value") {
+				return []string{"on"}
+			}
+		}
+
+		return []string{getAttribute(n, "value")}
+	case "textarea":
+		return []string{textContent(n)}
+	case "select":
+		var values, enabled []string
+
+		var walk func(c *html.Node)
+
+		walk = func(c *html.Node) {
+			for o := c.FirstChild; o != nil; o = o.NextSibling {
+				switch {
+				case o.Type != html.ElementNode:
+				case o.Data == "optgroup" && !HasAttribute(o, "disabled"):
+					walk(o)
+				case o.Data == "option" && !HasAttribute(o, "disabled"):
+					value := getAttribute(o, "value")
+
+					if !HasAttribute(o, "value") {
+						value = textContent(o)
+					}
+
+					enabled = append(enabled, value)
+
+					if HasAttribute(o, "selected") {
+						values = append(values, value)
+					}
+				}
+			}
+		}
+
+		walk(n)
+
+		if len(values) == 0 && !HasAttribute(n, "multiple") && len(enabled) > 0 {
+			// a single select with no selected option submits its first option.
+			values = enabled[:1]
+		}
+
+		return values
+	default:
+		return nil
+	}
+}
+
+// textContent returns the concatenated text of the text nodes within n.
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			sb.WriteString(c.Data)
+		} else {
+			sb.WriteString(textContent(c))
+		}
+	}
+
+	return sb.String()
+}
+
+// buildHiddenInput builds an <input type="hidden"> with the given name and value.
+func buildHiddenInput(name, value string) *html.Node {
+	return &html.Node{
+		Type: html.ElementNode,
+		Data: "input",
+		Attr: []html.Attribute{
+			{
+				Key: "type",
+				Val: "hidden",
+			},
+			{
+				Key: "name",
+				Val: name,
+			},
+			{
+				Key: "value",
+				Val: value,
+			},
+		},
+	}
+}
+
+// buildHoneypot builds a honeypot input with the given name, within a <div> which is positioned off screen.
+// The input is not a hidden input, as bots are less likely to fill those in.
+func buildHoneypot(name string) *html.Node {
+	div := &html.Node{
+		Type: html.ElementNode,
+		Data: "div",
+		Attr: []html.Attribute{
+			{
+				Key: "style",
+				Val: "position: absolute; left: -10000px;",
+			},
+			{
+				Key: "aria-hidden",
+				Val: "true",
+			},
+		},
+	}
+
+	div.AppendChild(&html.Node{
+		Type: html.ElementNode,
+		Data: "input",
+		Attr: []html.Attribute{
+			{
+				Key: "type",
+				Val: "text",
+			},
+			{
+				Key: "name",
+				Val: name,
+			},
+			{
+				Key: "value",
+				Val: "",
+			},
+			{
+				Key: "tabindex",
+				Val: "-1",
+			},
+			{
+				Key: "autocomplete",
+				Val: "off",
+			},
+		},
+	})
+
+	return div
+}
+
 func (h *HTMLEncoder) buildCSRFTokenField(parent *html.Node) error {
 	token := csrf.TemplateField(h.r)
 
@@ -2,16 +2,24 @@ package formulate
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/gorilla/csrf"
+	"golang.org/x/net/html"
 )
 
 type YourDetails struct {
@@ -293,73 +301,2468 @@ func TestHtmlEncoder_Encode(t *testing.T) {
 		}
 	})
 
-	t.Run("Encoder with Show Conditions", func(t *testing.T) {
+	t.Run("Encoder renders values which failed validation", func(t *testing.T) {
 		type test struct {
-			Name                    string
-			AddressLine1            string `show:"visible"`
-			AddressLine2            string `show:"visible,invisible"`
-			PostCode                string `show:"invisible"`
-			HiddenByGlobalCondition int
+			Age         int
+			CountryCode string
+		}
+
+		store := NewMemoryValidationStore()
+
+		if err := store.AddValidationError("Age", ValidationError{Error: "You must be over 20!", Value: int64(15)}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if err := store.AddValidationError("CountryCode", ValidationError{Error: "Country codes must be 3 letters and uppercase", Value: "uk"}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+		m.SetValidationStore(store)
+
+		if err := m.Encode(&test{Age: 25, CountryCode: "GBR"}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		if !strings.Contains(b, `name="Age" id="Age" value="15"`) {
+			t.Error("Expected rejected Age value to be rendered")
+		}
+
+		if !strings.Contains(b, `name="CountryCode" id="CountryCode" value="uk"`) {
+			t.Error("Expected rejected CountryCode value to be rendered")
+		}
+	})
+
+	t.Run("Encoder renders ARIA attributes", func(t *testing.T) {
+		type test struct {
+			Email    string `help:"We won't share it." required:"true"`
+			Age      int
+			Nickname string
+			Rating   Rating `help:"How was your stay?" required:"true"`
+			Toppings toppings
+		}
+
+		store := NewMemoryValidationStore()
+
+		if err := store.AddValidationError("Email", ValidationError{Error: "Enter a valid email address", Value: "foo"}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if err := store.AddValidationError("Toppings", ValidationError{Error: "Choose at most two toppings"}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+		m.SetValidationStore(store)
+
+		if err := m.Encode(&test{}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		for _, expected := range []string{
+			`<input type="text" name="Email" id="Email" value="foo" required="required" aria-describedby="Email-errors Email-help" aria-invalid="true" aria-required="true"/>`,
+			`<div id="Email-errors">Enter a valid email address</div>`,
+			`<div id="Email-help">We won&#39;t share it.</div>`,
+			`<input type="number" name="Age" id="Age" value="0"/>`,
+			`<input type="text" name="Nickname" id="Nickname" value=""/><div></div>`,
+			`<input type="radio" value="1" id="Rating0" name="Rating" data-rating-value="1" aria-describedby="Rating-help" aria-required="true"/>`,
+			`<input type="radio" value="5" id="Rating4" name="Rating" data-rating-value="5" aria-describedby="Rating-help" aria-required="true"/>`,
+			`<input type="checkbox" value="cheese" id="Toppings0" name="Toppings" aria-describedby="Toppings-errors" aria-invalid="true"/>`,
+			`<input type="checkbox" value="olives" id="Toppings3" name="Toppings" aria-describedby="Toppings-errors" aria-invalid="true" checked=""/>`,
+		} {
+			if !strings.Contains(b, expected) {
+				t.Errorf("Expected %s in output, got: %s", expected, b)
+			}
+		}
+	})
+
+	t.Run("Encoder renders hints within labels and help text below inputs", func(t *testing.T) {
+		type test struct {
+			Nickname string `hint:"optional" help:"What your friends call you."`
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+		m.SetLabelSuffix(":")
+
+		if err := m.Encode(&test{}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		expected := `<div><fieldset><div><label for="Nickname">Nickname:<small>optional</small></label><div>` +
+			`<input type="text" name="Nickname" id="Nickname" value="" aria-describedby="Nickname-help"/>` +
+			`<div id="Nickname-help">What your friends call you.</div></div></div></fieldset></div>`
+
+		assertEquals(t, expected, buf.String())
+	})
+
+	t.Run("Encoder renders requiredwhen conditions as data attributes", func(t *testing.T) {
+		type test struct {
+			ConfirmedEmail bool
+			Email          string `requiredwhen:"ConfirmedEmail=on"`
+			Invalid        string `requiredwhen:"ConfirmedEmail"`
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&test{}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		if !strings.Contains(b, `<input type="text" name="Email" id="Email" value="" data-required-when="ConfirmedEmail" data-required-when-value="on"/>`) {
+			t.Errorf("Expected requiredwhen data attributes, got: %s", b)
+		}
+
+		if strings.Count(b, "data-required-when=") != 1 {
+			t.Errorf("Expected invalid requiredwhen condition to be ignored, got: %s", b)
+		}
+	})
+
+	t.Run("Encoder renders HTML help text", func(t *testing.T) {
+		type test struct {
+			Consent bool   `helphtml:"See our <a href=\"/privacy\">privacy policy</a>."`
+			Notes   string `help:"<b>Not</b> HTML."`
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&test{}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		if !strings.Contains(b, `<div id="Consent-help">See our <a href="/privacy">privacy policy</a>.</div>`) {
+			t.Errorf("Expected HTML help text to be rendered as HTML, got: %s", b)
+		}
+
+		if !strings.Contains(b, `<div id="Notes-help">&lt;b&gt;Not&lt;/b&gt; HTML.</div>`) {
+			t.Errorf("Expected plain help text to be escaped, got: %s", b)
+		}
+	})
+
+	t.Run("Encoder renders disabled structs as disabled fieldsets", func(t *testing.T) {
+		type card struct {
+			Number string
+		}
+
+		type billing struct {
+			Name string
+			Card card
+		}
+
+		type contact struct {
+			Phone string
+		}
+
+		type test struct {
+			Billing billing `disabled:"true"`
+			Contact contact `disabled:"true" show:"contents"`
+			Notes   string  `disabled:"true"`
+			Email   string
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&test{}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		for _, expected := range []string{
+			`<fieldset disabled=""><legend>Billing</legend>`,
+			`<input type="text" name="Billing.Name" id="Billing.Name" value=""/>`,
+			`<fieldset><legend>Card</legend>`,
+			`<input type="text" name="Billing.Card.Number" id="Billing.Card.Number" value=""/>`,
+			`<input type="text" name="Contact.Phone" id="Contact.Phone" value="" disabled=""/>`,
+			`<input type="text" name="Notes" id="Notes" value="" disabled=""/>`,
+			`<input type="text" name="Email" id="Email" value=""/>`,
+		} {
+			if !strings.Contains(b, expected) {
+				t.Errorf("Expected %s in output, got: %s", expected, b)
+			}
+		}
+	})
+
+	t.Run("Encoder renders accesskey attribute", func(t *testing.T) {
+		type test struct {
+			Search  string `accesskey:"s"`
+			Invalid string `accesskey:"abc"`
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&test{}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		if !strings.Contains(b, `name="Search" id="Search" value="" accesskey="s"`) {
+			t.Error("Expected accesskey attribute to be rendered")
+		}
+
+		if strings.Count(b, "accesskey") != 1 {
+			t.Error("Expected invalid accesskey to be ignored")
+		}
+
+		m = NewEncoder(new(bytes.Buffer), nil, nil)
+		m.SetStrictAccessKeys(true)
+
+		if err := m.Encode(&test{}); !errors.Is(err, ErrInvalidAccessKey) {
+			t.Errorf("Expected ErrInvalidAccessKey, got: %v", err)
+		}
+	})
+
+	t.Run("Encoder returns error for unsupported kinds", func(t *testing.T) {
+		type test struct {
+			Name     string
+			Callback func()
+			Events   chan int
+			Phase    complex128
+			Handlers struct {
+				OnSave func() error
+			}
+		}
+
+		m := NewEncoder(new(bytes.Buffer), nil, nil)
+
+		if err := m.Encode(&test{}); !errors.Is(err, ErrUnsupportedKind) {
+			t.Errorf("Expected ErrUnsupportedKind, got: %v", err)
+		}
+
+		buf := new(bytes.Buffer)
+		m = NewEncoder(buf, nil, nil)
+		m.SetSkipUnsupportedFields(true)
+
+		if err := m.Encode(&test{}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if !strings.Contains(buf.String(), `name="Name"`) || strings.Contains(buf.String(), "Callback") ||
+			strings.Contains(buf.String(), "Events") || strings.Contains(buf.String(), "Phase") || strings.Contains(buf.String(), "OnSave") {
+			t.Error("Expected unsupported field to be skipped")
+		}
+	})
+
+	t.Run("Encoder renders an input for each map entry", func(t *testing.T) {
+		type test struct {
+			Scores   map[string]int  `elem:"map"`
+			Features map[string]bool `elem:"map"`
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&test{Scores: map[string]int{"bob": 3, "alice": 5}, Features: map[string]bool{"beta": true}}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		if !strings.Contains(b, `<label for="Scores[alice]">alice</label><input type="number" name="Scores[alice]" id="Scores[alice]" value="5"/>`) {
+			t.Error("Expected input for Scores[alice]")
+		}
+
+		if strings.Index(b, "Scores[alice]") > strings.Index(b, "Scores[bob]") {
+			t.Error("Expected map entries to be sorted by key")
+		}
+
+		if !strings.Contains(b, `<input type="hidden" name="Features[beta]" value="false"/><input type="checkbox" name="Features[beta]" id="Features[beta]" checked="checked"/>`) {
+			t.Error("Expected checkbox and hidden fallback for Features[beta]")
+		}
+	})
+
+	t.Run("Encoder renders read only fields with a hidden mirror", func(t *testing.T) {
+		type test struct {
+			Username string `readonly:"true"`
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&test{Username: "jane"}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if !strings.Contains(buf.String(), `<input type="text" name="Username" id="Username" value="jane" readonly="readonly"/><input type="hidden" name="Username.readonly" value="jane"/>`) {
+			t.Errorf("Expected read only input and hidden mirror, got: %s", buf.String())
+		}
+	})
+
+	t.Run("Encoder renders search inputs and inputmode", func(t *testing.T) {
+		type test struct {
+			Query      string `type:"search"`
+			PostalCode string `inputmode:"numeric"`
+			Quantity   int    `inputmode:"decimal"`
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&test{}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		if !strings.Contains(b, `<input type="search" name="Query" id="Query" value=""/>`) {
+			t.Error("Expected search input")
+		}
+
+		if !strings.Contains(b, `<input type="text" name="PostalCode" id="PostalCode" value="" inputmode="numeric"/>`) {
+			t.Error("Expected inputmode on text input")
+		}
+
+		if !strings.Contains(b, `<input type="number" name="Quantity" id="Quantity" value="0" inputmode="decimal"/>`) {
+			t.Error("Expected inputmode on number input")
+		}
+	})
+
+	t.Run("Custom encoder has ARIA context", func(t *testing.T) {
+		type test struct {
+			Rating ariaRating `required:"true"`
+		}
+
+		store := NewMemoryValidationStore()
+
+		if err := store.AddValidationError("Rating", ValidationError{Error: "Please choose a rating"}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+		m.SetValidationStore(store)
+
+		if err := m.Encode(&test{}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		if !strings.Contains(b, `<label for="Rating" id="Rating-label">Rating</label>`) {
+			t.Error("Expected label with id")
+		}
+
+		if !strings.Contains(b, `<div role="slider" aria-labelledby="Rating-label" aria-required="true" aria-invalid="true"></div>`) {
+			t.Error("Expected ARIA attributes on custom element")
+		}
+	})
+
+	t.Run("Encoder renders autocomplete attribute", func(t *testing.T) {
+		type test struct {
+			Name     string
+			Password Password `autocomplete:"new-password"`
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&test{}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		if !strings.Contains(b, `<input type="password" name="Password" id="Password" value="" autocomplete="new-password"/>`) {
+			t.Error("Expected autocomplete attribute")
+		}
+
+		if strings.Count(b, "autocomplete") != 1 {
+			t.Error("Expected autocomplete attribute to be omitted when not set")
+		}
+	})
+
+	t.Run("Encoder renders textarea rows and cols", func(t *testing.T) {
+		type test struct {
+			Description string `elem:"textarea" rows:"10" cols:"80"`
+			Notes       string `elem:"textarea"`
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&test{}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		if !strings.Contains(b, `<textarea name="Description" id="Description" rows="10" cols="80"></textarea>`) {
+			t.Error("Expected textarea with rows and cols")
+		}
+
+		if !strings.Contains(b, `<textarea name="Notes" id="Notes"></textarea>`) {
+			t.Error("Expected textarea without rows and cols")
+		}
+	})
+
+	t.Run("Encoder renders a datalist for SuggestText", func(t *testing.T) {
+		type test struct {
+			Colour SuggestText
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&test{Colour: NewSuggestText("red", "red", "green", "blue")}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if !strings.Contains(buf.String(), `<input type="text" name="Colour" id="Colour" value="red" list="Colour-datalist"/><datalist id="Colour-datalist"><option value="red"></option><option value="green"></option><option value="blue"></option></datalist>`) {
+			t.Error("Expected text input and datalist")
+		}
+	})
+
+	t.Run("Encoder renders a datalist for string types which implement Datalist", func(t *testing.T) {
+		type test struct {
+			Colour colourSuggestion
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&test{Colour: "red"}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if !strings.Contains(buf.String(), `<input type="text" name="Colour" id="Colour" value="red" list="Colour-datalist"/><datalist id="Colour-datalist"><option value="red"></option><option value="green"></option></datalist>`) {
+			t.Errorf("Expected text input and datalist, got: %s", buf.String())
+		}
+
+		var x test
+
+		if err := NewDecoder(url.Values{"Colour": {"purple"}}).Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, x.Colour, colourSuggestion("purple"))
+	})
+
+	t.Run("Encoder renders time formats", func(t *testing.T) {
+		type test struct {
+			Default  time.Time
+			Birthday time.Time `format:"date"`
+			Alarm    time.Time `format:"time"`
+			Expiry   time.Time `format:"month"`
+		}
+
+		tm := time.Date(2020, time.May, 28, 15, 28, 0, 0, time.UTC)
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&test{Default: tm, Birthday: tm, Alarm: tm, Expiry: tm}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		for _, expected := range []string{
+			`<input type="datetime-local" name="Default" id="Default" value="2020-05-28T15:28"/>`,
+			`<input type="date" name="Birthday" id="Birthday" value="2020-05-28"/>`,
+			`<input type="time" name="Alarm" id="Alarm" value="15:28"/>`,
+			`<input type="month" name="Expiry" id="Expiry" value="2020-05"/>`,
+		} {
+			if !strings.Contains(b, expected) {
+				t.Errorf("Expected %s in output", expected)
+			}
+		}
+	})
+
+	t.Run("Encoder renders computed fields", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&computedTest{FirstName: "Jane", LastName: "Doe"}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		if !strings.Contains(b, `<label for="FullName" id="FullName-label">Full Name</label><div><output id="FullName">Jane Doe</output>`) {
+			t.Errorf("Expected computed FullName field, got: %s", b)
+		}
+
+		if !strings.Contains(b, `<output id="Monogram">JD</output>`) {
+			t.Errorf("Expected computed Monogram field from pointer receiver, got: %s", b)
+		}
+
+		type invalid struct {
+			Missing struct{} `computed:"Missing"`
+		}
+
+		if err := NewEncoder(ioutil.Discard, nil, nil).Encode(&invalid{}); !errors.Is(err, ErrInvalidComputedField) {
+			t.Errorf("Expected ErrInvalidComputedField, got: %v", err)
+		}
+	})
+
+	t.Run("Encoder renders optgroups in a deterministic order", func(t *testing.T) {
+		type test struct {
+			Produce groupedSelect
+		}
+
+		expected := `<select name="Produce" id="Produce" multiple=""><option value="none">None</option>` +
+			`<optgroup label="Fruit"><option value="apple">Apple</option><option value="banana" selected="">Banana</option></optgroup>` +
+			`<optgroup label="Vegetables"><option value="carrot">Carrot</option><option value="leek" selected="">Leek</option></optgroup></select>`
+
+		for i := 0; i < 10; i++ {
+			buf := new(bytes.Buffer)
+			m := NewEncoder(buf, nil, nil)
+
+			if err := m.Encode(&test{Produce: groupedSelect{"banana", "leek"}}); err != nil {
+				t.Error(err)
+				return
+			}
+
+			if !strings.Contains(buf.String(), expected) {
+				t.Errorf("Expected %s in output, got: %s", expected, buf.String())
+				return
+			}
+		}
+	})
+
+	t.Run("Encoder selects the current value of numeric single selects", func(t *testing.T) {
+		type test struct {
+			Priority priority
+			Ratio    ratio
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&test{Priority: 2, Ratio: 1}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		for _, expected := range []string{
+			`<select name="Priority" id="Priority"><option value="1">Low</option><option value="2" selected="">Medium</option><option value="3">High</option></select>`,
+			`<select name="Ratio" id="Ratio"><option value="0.5">Half</option><option value="1" selected="">Whole</option></select>`,
+		} {
+			if !strings.Contains(b, expected) {
+				t.Errorf("Expected %s in output, got: %s", expected, b)
+			}
+		}
+	})
+
+	t.Run("Encoder renders checkbox groups", func(t *testing.T) {
+		type test struct {
+			Toppings toppings
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&test{Toppings: toppings{"ham"}}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		expected := `<div id="Toppings"><input type="hidden" name="Toppings" value=""/>` +
+			`<label for="Toppings0">Cheese</label><input type="checkbox" value="cheese" id="Toppings0" name="Toppings"/>` +
+			`<label for="Toppings1">Ham</label><input type="checkbox" value="ham" id="Toppings1" name="Toppings" checked=""/>` +
+			`<label for="Toppings2">Pineapple</label><input type="checkbox" value="pineapple" id="Toppings2" name="Toppings" disabled=""/>` +
+			`<label for="Toppings3">Olives</label><input type="checkbox" value="olives" id="Toppings3" name="Toppings" checked=""/></div>`
+
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected %s in output, got: %s", expected, buf.String())
+		}
+	})
+
+	t.Run("Encoder renders accept attribute for file inputs", func(t *testing.T) {
+		type test struct {
+			Avatar string `type:"file" accept:"image/png,image/jpeg"`
+			Name   string `accept:".pdf"`
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&test{}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		if !strings.Contains(b, `<input type="file" name="Avatar" id="Avatar" value="" accept="image/png,image/jpeg"/>`) {
+			t.Errorf("Expected accept attribute on file input, got: %s", b)
+		}
+
+		if !strings.Contains(b, `<input type="text" name="Name" id="Name" value=""/>`) {
+			t.Errorf("Expected no accept attribute on text input, got: %s", b)
+		}
+	})
+
+	t.Run("Encoder renders display values from methods", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&accountTest{AccountNumber: "12345678", SortCode: "123456"}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if !strings.Contains(buf.String(), `<input type="text" name="AccountNumber" id="AccountNumber" value="1234 5678" readonly="readonly"/>`) {
+			t.Errorf("Expected formatted account number, got: %s", buf.String())
+		}
+
+		if !strings.Contains(buf.String(), `<input type="text" name="SortCode" id="SortCode" value="123456"/>`) {
+			t.Errorf("Expected editable sort code to be rendered with its own value, got: %s", buf.String())
+		}
+
+		x := accountTest{AccountNumber: "12345678"}
+
+		if err := NewDecoder(url.Values{"AccountNumber": {"1234 5678"}, "SortCode": {"654321"}}).Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, x.AccountNumber, "12345678")
+		assertEquals(t, x.SortCode, "654321")
+	})
+
+	t.Run("Encoder renders nullable select with empty option", func(t *testing.T) {
+		type test struct {
+			Pet      *Pet
+			OtherPet *Pet
+		}
+
+		cat := Pet("cat")
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&test{OtherPet: &cat}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		for _, expected := range []string{
+			`<select name="Pet" id="Pet"><option value="" selected="">— none —</option><option value="dog">Dog</option>`,
+			`<select name="OtherPet" id="OtherPet"><option value="">— none —</option><option value="dog">Dog</option><option value="cat" selected="">Cat</option>`,
+		} {
+			if !strings.Contains(b, expected) {
+				t.Errorf("Expected %s in output, got: %s", expected, b)
+			}
+		}
+	})
+
+	t.Run("Encoder renders month and week inputs", func(t *testing.T) {
+		type test struct {
+			Month  Month
+			Week   Week
+			Sprint time.Time `format:"week"`
+		}
+
+		tm := time.Date(2021, time.January, 3, 15, 28, 0, 0, time.UTC)
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+
+		if err := m.Encode(&test{Month: Month{tm}, Week: Week{tm}, Sprint: tm}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		for _, expected := range []string{
+			`<input type="month" name="Month" id="Month" value="2021-01"/>`,
+			`<input type="week" name="Week" id="Week" value="2020-W53"/>`,
+			`<input type="week" name="Sprint" id="Sprint" value="2020-W53"/>`,
+		} {
+			if !strings.Contains(b, expected) {
+				t.Errorf("Expected %s in output, got: %s", expected, b)
+			}
+		}
+	})
+
+	t.Run("Encoder renders time in location", func(t *testing.T) {
+		london, err := time.LoadLocation("Europe/London")
+
+		if err != nil {
+			t.Skip(err)
+		}
+
+		type test struct {
+			Meeting time.Time
+			Flight  time.Time `tz:"America/New_York"`
+		}
+
+		tm := time.Date(2020, time.May, 28, 14, 0, 0, 0, time.UTC)
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+		m.SetTimeLocation(london)
+
+		if err := m.Encode(&test{Meeting: tm, Flight: tm}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		for _, expected := range []string{
+			`<input type="datetime-local" name="Meeting" id="Meeting" value="2020-05-28T15:00"/>`,
+			`<input type="datetime-local" name="Flight" id="Flight" value="2020-05-28T10:00"/>`,
+		} {
+			if !strings.Contains(b, expected) {
+				t.Errorf("Expected %s in output", expected)
+			}
+		}
+	})
+
+	t.Run("Encoder renders values rejected by a CustomDecoder", func(t *testing.T) {
+		type test struct {
+			Broken failingDecoder
+		}
+
+		for _, invalidInputAsValidationError := range []bool{false, true} {
+			store := NewMemoryValidationStore()
+
+			dec := NewDecoder(url.Values{"Broken": {"oops"}})
+			dec.SetValidationStore(store)
+			dec.SetInvalidInputAsValidationError(invalidInputAsValidationError)
+			dec.SetCustomDecoderErrorsAsValidation(true)
+
+			var x test
+
+			if err := dec.Decode(&x); !errors.Is(err, ErrFormFailedValidation) {
+				t.Errorf("Expected validation error, got: %v", err)
+				return
+			}
+
+			buf := new(bytes.Buffer)
+			m := NewEncoder(buf, nil, nil)
+			m.SetValidationStore(store)
+
+			if err := m.Encode(&x); err != nil {
+				t.Error(err)
+				return
+			}
+
+			if !strings.Contains(buf.String(), `name="Broken" id="Broken" value="oops"`) {
+				t.Errorf("Expected rejected value to be rendered, got: %s", buf.String())
+			}
+		}
+	})
+
+	t.Run("Encoder renders rejected values of non-string fields", func(t *testing.T) {
+		type test struct {
+			Age    int
+			Amount big.Rat
+			When   time.Time
+			Notes  []string `elem:"textarea"`
+		}
+
+		store := NewMemoryValidationStore()
+
+		dec := NewDecoder(url.Values{"Age": {"abc"}, "Amount": {"1/x"}, "When": {"tomorrow"}, "Notes": {"[oops"}})
+		dec.SetValidationStore(store)
+		dec.SetInvalidInputAsValidationError(true)
+
+		var x test
+
+		if err := dec.Decode(&x); !errors.Is(err, ErrFormFailedValidation) {
+			t.Errorf("Expected validation error, got: %v", err)
+			return
+		}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+		m.SetValidationStore(store)
+
+		if err := m.Encode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		for _, expected := range []string{
+			`name="Age" id="Age" value="abc"`,
+			`name="Amount" id="Amount" value="1/x"`,
+			`name="When" id="When" value="tomorrow"`,
+			`<textarea name="Notes" id="Notes">[oops</textarea>`,
+		} {
+			if !strings.Contains(buf.String(), expected) {
+				t.Errorf("Expected %s, got: %s", expected, buf.String())
+			}
+		}
+	})
+
+	t.Run("Encoder with Show Conditions", func(t *testing.T) {
+		type test struct {
+			Name                    string
+			AddressLine1            string `show:"visible"`
+			AddressLine2            string `show:"visible,invisible"`
+			PostCode                string `show:"invisible"`
+			HiddenByGlobalCondition int
+		}
+
+		s := &test{}
+
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+		m.SetFormat(true)
+		m.AddShowCondition("visible", func(field StructField) bool {
+			return true
+		})
+		m.AddShowCondition("invisible", func(field StructField) bool {
+			return false
+		})
+		m.AddGlobalShowCondition(func(field StructField) bool {
+			return field.Name != "HiddenByGlobalCondition"
+		})
+
+		if err := m.Encode(s); err != nil {
+			t.Error(err)
+		}
+
+		b := buf.String()
+
+		if !strings.Contains(b, "AddressLine1") {
+			t.Fail()
+		}
+
+		if strings.Contains(b, "PostCode") {
+			t.Fail()
+		}
+
+		if strings.Contains(b, "AddressLine2") {
+			t.Fail()
+		}
+
+		if strings.Contains(b, "HiddenByGlobalCondition") {
+			t.Fail()
+		}
+	})
+}
+
+type colourSuggestion string
+
+func (c colourSuggestion) DatalistOptions() []Option {
+	return []Option{{Value: "red"}, {Value: "green"}}
+}
+
+type accountTest struct {
+	AccountNumber string `display:"FormattedAccountNumber" readonly:"true"`
+	SortCode      string `display:"FormattedSortCode"`
+}
+
+func (a accountTest) FormattedAccountNumber() string {
+	return a.AccountNumber[:4] + " " + a.AccountNumber[4:]
+}
+
+func (a accountTest) FormattedSortCode() string {
+	return a.SortCode[:2] + "-" + a.SortCode[2:4] + "-" + a.SortCode[4:]
+}
+
+type computedTest struct {
+	FirstName string
+	LastName  string
+	FullName  struct{} `computed:"Name"`
+	Monogram  struct{} `computed:"Initials"`
+}
+
+func (c computedTest) Name() string {
+	return c.FirstName + " " + c.LastName
+}
+
+func (c *computedTest) Initials() string {
+	return c.FirstName[:1] + c.LastName[:1]
+}
+
+type ariaRating int
+
+func (a ariaRating) BuildFormElement(key string, parent *html.Node, field StructField, decorator Decorator) error {
+	n := &html.Node{
+		Type: html.ElementNode,
+		Data: "div",
+		Attr: []html.Attribute{
+			{Key: "role", Val: "slider"},
+			{Key: "aria-labelledby", Val: field.LabelID},
+			{Key: "aria-required", Val: strconv.FormatBool(field.Required())},
+			{Key: "aria-invalid", Val: strconv.FormatBool(len(field.ValidationErrors) > 0)},
+		},
+	}
+
+	parent.AppendChild(n)
+
+	return nil
+}
+
+type groupedSelect []string
+
+func (g groupedSelect) SelectMultiple() bool {
+	return true
+}
+
+func (g groupedSelect) SelectOptions() []Option {
+	fruit, vegetables := "Fruit", "Vegetables"
+
+	return []Option{
+		{Value: "apple", Label: "Apple", Group: &fruit},
+		{Value: "carrot", Label: "Carrot", Group: &vegetables},
+		{Value: "none", Label: "None"},
+		{Value: "banana", Label: "Banana", Group: &fruit},
+		{Value: "leek", Label: "Leek", Group: &vegetables},
+	}
+}
+
+type toppings []string
+
+func (t toppings) CheckboxOptions() []Option {
+	return []Option{
+		{Value: "cheese", Label: "Cheese"},
+		{Value: "ham", Label: "Ham"},
+		{Value: "pineapple", Label: "Pineapple", Disabled: true},
+		{Value: "olives", Label: "Olives", Checked: NewCondition(true)},
+	}
+}
+
+type priority int
+
+func (p priority) SelectMultiple() bool {
+	return false
+}
+
+func (p priority) SelectOptions() []Option {
+	return []Option{
+		{Value: 1, Label: "Low"},
+		{Value: 2, Label: "Medium"},
+		{Value: 3, Label: "High"},
+	}
+}
+
+type ratio float64
+
+func (r ratio) SelectMultiple() bool {
+	return false
+}
+
+func (r ratio) SelectOptions() []Option {
+	return []Option{
+		{Value: 0.5, Label: "Half"},
+		{Value: 1.0, Label: "Whole"},
+	}
+}
+
+type numberIndexedSelect []int
+
+func (n numberIndexedSelect) SelectMultiple() bool {
+	return true
+}
+
+func (n numberIndexedSelect) SelectOptions() []Option {
+	return []Option{
+		{
+			Value: 0,
+			Label: "Zero",
+		},
+		{
+			Value: 1,
+			Label: "One",
+		},
+		{
+			Value: 2,
+			Label: "Two",
+		},
+	}
+}
+
+func TestHTMLEncoder_EncodeContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	m := NewEncoder(new(bytes.Buffer), nil, nil)
+
+	if err := m.EncodeContext(ctx, &YourDetails{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+}
+
+func TestHTMLEncoder_SetLocale(t *testing.T) {
+	type test struct {
+		Population int       `type:"text" readonly:"true"`
+		Area       float64   `type:"text" readonly:"true"`
+		Founded    time.Time `type:"text" disabled:"true"`
+		Visitors   int
+		Residents  int `type:"text"`
+	}
+
+	data := &test{
+		Population: 1234567,
+		Area:       1572.5,
+		Founded:    time.Date(1066, time.October, 14, 9, 30, 0, 0, time.UTC),
+		Visitors:   20000,
+		Residents:  1234567,
+	}
+
+	locales := []struct {
+		locale   Locale
+		expected []string
+	}{
+		{
+			locale: BasicLocale{DecimalSeparator: ".", GroupSeparator: ",", TimeLayout: "02/01/2006"},
+			expected: []string{
+				`name="Population" id="Population" value="1,234,567"`,
+				`name="Area" id="Area" value="1,572.5"`,
+				`name="Founded" id="Founded" value="14/10/1066"`,
+			},
+		},
+		{
+			locale: BasicLocale{DecimalSeparator: ",", GroupSeparator: ".", TimeLayout: "02.01.2006"},
+			expected: []string{
+				`name="Population" id="Population" value="1.234.567"`,
+				`name="Area" id="Area" value="1.572,5"`,
+				`name="Founded" id="Founded" value="14.10.1066"`,
+			},
+		},
+	}
+
+	for _, l := range locales {
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+		m.SetLocale(l.locale)
+
+		if err := m.Encode(data); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		for _, expected := range l.expected {
+			if !strings.Contains(b, expected) {
+				t.Errorf("Expected %s in output", expected)
+			}
+		}
+
+		// machine readable inputs are not formatted
+		if !strings.Contains(b, `<input type="number" name="Visitors" id="Visitors" value="20000"/>`) {
+			t.Error("Expected number input to be unformatted")
+		}
+
+		// editable text inputs are not formatted, so that they can be decoded.
+		if !strings.Contains(b, `<input type="text" name="Residents" id="Residents" value="1234567"/>`) {
+			t.Errorf("Expected editable text input to be unformatted, got: %s", b)
+		}
+
+		var out test
+
+		if err := NewDecoder(url.Values{"Visitors": {"20000"}, "Residents": {"1234567"}}).Decode(&out); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, data.Residents, out.Residents)
+	}
+}
+
+func TestHTMLEncoder_SetFieldWindow(t *testing.T) {
+	type test struct {
+		Name         string
+		AddressLine1 string
+		AddressLine2 string
+		PostCode     string
+	}
+
+	buf := new(bytes.Buffer)
+	m := NewEncoder(buf, nil, nil)
+	m.SetFieldWindow(1, 2)
+
+	if err := m.Encode(&test{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	if strings.Contains(b, `name="Name"`) {
+		t.Error("Expected Name to be outside of the field window")
+	}
+
+	if !strings.Contains(b, `name="AddressLine1"`) || !strings.Contains(b, `name="AddressLine2"`) {
+		t.Error("Expected AddressLine1 and AddressLine2 to be inside the field window")
+	}
+
+	if strings.Contains(b, `name="PostCode"`) {
+		t.Error("Expected PostCode to be outside of the field window")
+	}
+}
+
+type minLengthValidator struct {
+	length int
+}
+
+func (m minLengthValidator) Validate(value interface{}) (ok bool, message string) {
+	if s, isString := value.(string); isString && len(s) < m.length {
+		return false, fmt.Sprintf("must be at least %d characters", m.length)
+	}
+
+	return true, ""
+}
+
+func (m minLengthValidator) TagName() string {
+	return "minLength"
+}
+
+func (m minLengthValidator) HTMLAttributes() []html.Attribute {
+	return []html.Attribute{{Key: "minlength", Val: strconv.Itoa(m.length)}}
+}
+
+func TestHTMLEncoder_AddValidators(t *testing.T) {
+	type test struct {
+		Username string `validators:"minLength"`
+		Nickname string `validators:"minLength"`
+		Bio      string
+	}
+
+	buf := new(bytes.Buffer)
+	m := NewEncoder(buf, nil, nil)
+	m.AddValidators(minLengthValidator{length: 5})
+
+	if err := m.Encode(&test{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	if !strings.Contains(b, `<input type="text" name="Username" id="Username" value="" minlength="5"/>`) {
+		t.Errorf("Expected minlength attribute from validator, got: %s", b)
+	}
+
+	if strings.Count(b, `minlength="5"`) != 2 {
+		t.Errorf("Expected minlength attribute on Username and Nickname only, got: %s", b)
+	}
+}
+
+type rootClassDecorator struct {
+	nilDecorator
+}
+
+func (rootClassDecorator) RootNode(n *html.Node) {
+	AppendClass(n, "form")
+}
+
+func TestNewEncoderWithOptions(t *testing.T) {
+	type test struct {
+		Name string
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	buf := new(bytes.Buffer)
+	m := NewEncoderWithOptions(buf, WithRequest(r), WithDecorator(rootClassDecorator{}), WithFormat(true), WithCSRF(true))
+
+	assertEquals(t, m.r, r)
+	assertEquals(t, m.format, true)
+	assertEquals(t, m.csrfProtection, true)
+
+	m = NewEncoderWithOptions(buf, WithDecorator(rootClassDecorator{}))
+
+	if err := m.Encode(&test{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !strings.HasPrefix(buf.String(), `<div class="form"><fieldset><div><label for="Name">Name</label>`) {
+		t.Errorf("Expected root node to be decorated, got: %s", buf.String())
+	}
+}
+
+func TestHTMLEncoder_SetForm(t *testing.T) {
+	type test struct {
+		Name string
+	}
+
+	buf := new(bytes.Buffer)
+	m := NewEncoder(buf, nil, submitButtonDecorator{})
+	m.SetForm("/signup", http.MethodPost, html.Attribute{Key: "id", Val: "signup"}, html.Attribute{Key: "enctype", Val: "multipart/form-data"})
+	m.SetSubmitButton("Sign up")
+
+	if err := m.Encode(&test{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	if !strings.HasPrefix(b, `<form action="/signup" method="POST" id="signup" enctype="multipart/form-data"><div><fieldset>`) {
+		t.Errorf("Expected output to be wrapped in a form, got: %s", b)
+	}
+
+	if !strings.HasSuffix(b, `</fieldset><button type="submit" class="submit">Sign up</button></div></form>`) {
+		t.Errorf("Expected decorated submit button inside the form, got: %s", b)
+	}
+
+	buf.Reset()
+
+	if err := m.Encode(&test{Name: "b"}); err != nil {
+		t.Errorf("Expected the encoder to be reusable, got: %v", err)
+		return
+	}
+
+	if !strings.HasPrefix(buf.String(), `<form action="/signup" method="POST" id="signup" enctype="multipart/form-data"><div>`) {
+		t.Errorf("Expected output to be wrapped in a form, got: %s", buf.String())
+	}
+
+	// fields and buttons from the first call are not rendered again.
+	assertEquals(t, strings.Count(buf.String(), `name="Name"`), 1)
+	assertEquals(t, strings.Count(buf.String(), `value="b"`), 1)
+	assertEquals(t, strings.Count(buf.String(), `type="submit"`), 1)
+
+	buf.Reset()
+	m = NewEncoder(buf, nil, nil)
+	m.SetSubmitButton("Sign up")
+
+	if err := m.Encode(&test{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if strings.Contains(buf.String(), "button") {
+		t.Errorf("Expected no submit button without a form, got: %s", buf.String())
+	}
+}
+
+type submitButtonDecorator struct {
+	nilDecorator
+}
+
+func (submitButtonDecorator) SubmitButton(n *html.Node) {
+	AppendClass(n, "submit")
+}
+
+type requiredProgressDecorator struct {
+	nilDecorator
+}
+
+func (requiredProgressDecorator) RequiredProgress(n *html.Node, remaining, total int) {
+	AppendClass(n, "progress-"+strconv.Itoa(remaining))
+}
+
+func TestHTMLEncoder_SetRequiredProgress(t *testing.T) {
+	type test struct {
+		Name     string `required:"true"`
+		Email    Email  `required:"true"`
+		Age      int    `required:"true"`
+		Admin    string `required:"true" show:"adminOnly"`
+		Nickname string
+		Address  struct {
+			Postcode string `required:"true"`
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	m := NewEncoder(buf, nil, requiredProgressDecorator{})
+	m.SetRequiredProgress(true)
+	m.AddShowCondition("adminOnly", func(field StructField) bool {
+		return false
+	})
+
+	if err := m.Encode(&test{Name: "Jane", Age: 40}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !strings.HasPrefix(buf.String(), `<div><div class="progress-2">2 of 4 required fields remaining</div><fieldset>`) {
+		t.Errorf("Expected required progress summary, got: %s", buf.String())
+	}
+}
+
+func TestHTMLEncoder_AddRequiredCondition(t *testing.T) {
+	type test struct {
+		Choice      string
+		OtherChoice string `required:"whenOther"`
+		Unknown     string `required:"unregistered"`
+	}
+
+	for _, choice := range []string{"Other", "Apple"} {
+		t.Run(choice, func(t *testing.T) {
+			s := &test{Choice: choice}
+
+			buf := new(bytes.Buffer)
+			m := NewEncoder(buf, nil, nil)
+			m.AddRequiredCondition("whenOther", func(field StructField) bool {
+				return s.Choice == "Other"
+			})
+
+			if err := m.Encode(s); err != nil {
+				t.Error(err)
+				return
+			}
+
+			b := buf.String()
+
+			assertEquals(t, choice == "Other", strings.Contains(b, `<input type="text" name="OtherChoice" id="OtherChoice" value="" required="required" aria-required="true"/>`))
+			assertEquals(t, choice != "Other", strings.Contains(b, `<input type="text" name="OtherChoice" id="OtherChoice" value=""/>`))
+			assertEquals(t, true, strings.Contains(b, `<input type="text" name="Unknown" id="Unknown" value=""/>`))
+		})
+	}
+}
+
+func TestHTMLEncoder_SetTemplates(t *testing.T) {
+	type test struct {
+		Colour string `template:"colourPicker" help:"Pick a colour"`
+	}
+
+	templates := template.Must(template.New("").Parse(`{{ define "colourPicker" }}` +
+		`<input type="color" name="{{ .Name }}" id="{{ .ID }}" value="{{ .Value }}" aria-label="{{ .Label }}"/>` +
+		`{{ end }}`))
+
+	t.Run("Field rendered with template", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+		m.SetTemplates(templates)
+
+		if err := m.Encode(&test{Colour: "#ff0000"}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		expected := `<div><label for="Colour">Colour</label><div>` +
+			`<input type="color" name="Colour" id="Colour" value="#ff0000" aria-label="Colour"/>` +
+			`<div id="Colour-help">Pick a colour</div></div></div>`
+
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected field rendered with template, got: %s", buf.String())
+		}
+	})
+
+	t.Run("Unknown template", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+
+		if err := NewEncoder(buf, nil, nil).Encode(&test{}); !errors.Is(err, ErrUnknownTemplate) {
+			t.Errorf("Expected ErrUnknownTemplate, got: %v", err)
+		}
+	})
+}
+
+type resetControlDecorator struct {
+	nilDecorator
+}
+
+func (resetControlDecorator) ResetControl(n *html.Node, field StructField) {
+	n.Attr = append(n.Attr, html.Attribute{Key: "class", Val: "reset-" + field.Name})
+}
+
+func TestHTMLEncoder_SetFieldResetControls(t *testing.T) {
+	type test struct {
+		PageSize int `default:"10"`
+		Name     string
+	}
+
+	buf := new(bytes.Buffer)
+	m := NewEncoder(buf, nil, resetControlDecorator{})
+	m.SetFieldResetControls(true)
+
+	if err := m.Encode(&test{PageSize: 25}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := `<div><label for="PageSize">Page Size</label><div>` +
+		`<input type="number" name="PageSize" id="PageSize" value="25" data-default="10"/>` +
+		`<button type="button" data-reset-for="PageSize" class="reset-PageSize">Reset</button><div></div></div></div>` +
+		`<div><label for="Name">Name</label><div><input type="text" name="Name" id="Name" value=""/><div></div></div></div>`
+
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("Expected reset control for PageSize only, got: %s", buf.String())
+	}
+}
+
+type meterDecorator struct {
+	nilDecorator
+}
+
+func (meterDecorator) Meter(n *html.Node, field StructField) {
+	n.Attr = append(n.Attr, html.Attribute{Key: "class", Val: "meter"})
+}
+
+func (meterDecorator) Progress(n *html.Node, field StructField) {
+	n.Attr = append(n.Attr, html.Attribute{Key: "class", Val: "progress"})
+}
+
+func TestMeterAndProgress(t *testing.T) {
+	type test struct {
+		DiskUsage Meter    `min:"0" max:"100"`
+		Upload    Progress `max:"100"`
+		Name      string
+	}
+
+	buf := new(bytes.Buffer)
+
+	if err := NewEncoder(buf, nil, meterDecorator{}).Encode(&test{DiskUsage: 72.5, Upload: 40}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	for _, expected := range []string{
+		`<meter id="DiskUsage" value="72.5" min="0" max="100" class="meter">72.5</meter>`,
+		`<progress id="Upload" value="40" max="100" class="progress">40</progress>`,
+	} {
+		if !strings.Contains(b, expected) {
+			t.Errorf("Expected %s in output, got: %s", expected, b)
+		}
+	}
+
+	x := test{DiskUsage: 72.5, Upload: 40}
+
+	if err := NewDecoder(url.Values{"Name": {"Dave"}}).Decode(&x); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assertEquals(t, Meter(72.5), x.DiskUsage)
+	assertEquals(t, Progress(40), x.Upload)
+	assertEquals(t, "Dave", x.Name)
+}
+
+type ratingDecorator struct {
+	nilDecorator
+}
+
+func (ratingDecorator) Rating(n *html.Node, field StructField) {
+	n.Attr = append(n.Attr, html.Attribute{Key: "class", Val: "stars"})
+}
+
+func TestRating(t *testing.T) {
+	type test struct {
+		Overall Rating
+		Value   Rating `max:"3"`
+	}
+
+	buf := new(bytes.Buffer)
+
+	if err := NewEncoder(buf, nil, ratingDecorator{}).Encode(&test{Overall: 4, Value: 2}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	for _, expected := range []string{
+		`<div id="Overall" data-rating="5" class="stars"><label for="Overall0">1 of 5</label>` +
+			`<input type="radio" value="1" id="Overall0" name="Overall" data-rating-value="1"/>`,
+		`<input type="radio" value="4" id="Overall3" name="Overall" data-rating-value="4" checked=""/>`,
+		`<label for="Overall4">5 of 5</label><input type="radio" value="5" id="Overall4" name="Overall" data-rating-value="5"/></div>`,
+		`<div id="Value" data-rating="3" class="stars">`,
+		`<input type="radio" value="2" id="Value1" name="Value" data-rating-value="2" checked=""/>`,
+		`<label for="Value2">3 of 3</label><input type="radio" value="3" id="Value2" name="Value" data-rating-value="3"/></div>`,
+	} {
+		if !strings.Contains(b, expected) {
+			t.Errorf("Expected %s in output, got: %s", expected, b)
+		}
+	}
+
+	x := test{Overall: 4, Value: 2}
+
+	if err := NewDecoder(url.Values{"Overall": {"5"}}).Decode(&x); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assertEquals(t, Rating(5), x.Overall)
+	assertEquals(t, Rating(2), x.Value)
+
+	err := NewDecoder(url.Values{"Overall": {"five"}}).Decode(&x)
+
+	if !errors.Is(err, ErrInvalidRating) {
+		t.Errorf("Expected ErrInvalidRating, got: %v", err)
+	}
+}
+
+type planet string
+
+func (planet) Values() []string {
+	return []string{"Mercury", "Venus", "Earth"}
+}
+
+type urgency int
+
+func (urgency) Values() []Option {
+	return []Option{
+		{Value: 1, Label: "Low"},
+		{Value: 2, Label: "High"},
+	}
+}
+
+func TestEnum(t *testing.T) {
+	type test struct {
+		Planet   planet
+		Priority urgency
+	}
+
+	buf := new(bytes.Buffer)
+
+	if err := NewEncoder(buf, nil, nil).Encode(&test{Planet: "Venus", Priority: 2}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	for _, expected := range []string{
+		`<select name="Planet" id="Planet"><option value="Mercury">Mercury</option><option value="Venus" selected="">Venus</option><option value="Earth">Earth</option></select>`,
+		`<select name="Priority" id="Priority"><option value="1">Low</option><option value="2" selected="">High</option></select>`,
+	} {
+		if !strings.Contains(b, expected) {
+			t.Errorf("Expected %s in output, got: %s", expected, b)
+		}
+	}
+
+	var x test
+
+	if err := NewDecoder(url.Values{"Planet": {"Earth"}, "Priority": {"1"}}).Decode(&x); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assertEquals(t, planet("Earth"), x.Planet)
+	assertEquals(t, urgency(1), x.Priority)
+}
+
+func TestHTMLEncoder_SetCollectionRenderPolicy(t *testing.T) {
+	type test struct {
+		Limits   map[string]int
+		Tags     []string
+		Internal []string
+	}
+
+	buf := new(bytes.Buffer)
+	m := NewEncoder(buf, nil, nil)
+	m.SetCollectionRenderPolicy(func(field StructField, kind reflect.Kind) CollectionRenderMode {
+		switch field.Name {
+		case "Limits":
+			return CollectionRenderStructured
+		case "Internal":
+			return CollectionRenderSkip
+		default:
+			return CollectionRenderJSON
+		}
+	})
+
+	if err := m.Encode(&test{
+		Limits:   map[string]int{"cpu": 2},
+		Tags:     []string{"red"},
+		Internal: []string{"secret"},
+	}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	for _, expected := range []string{
+		`<div id="Limits"><div><label for="Limits[cpu]">cpu</label><input type="number" name="Limits[cpu]" id="Limits[cpu]" value="2"/></div></div>`,
+		`<textarea name="Tags" id="Tags">[
+  &#34;red&#34;
+]
+</textarea>`,
+	} {
+		if !strings.Contains(b, expected) {
+			t.Errorf("Expected %s in output, got: %s", expected, b)
+		}
+	}
+
+	if strings.Contains(b, "Internal") {
+		t.Errorf("Expected Internal to be skipped, got: %s", b)
+	}
+}
+
+func TestHTMLEncoder_ExtraAttributes(t *testing.T) {
+	type test struct {
+		Email string `attr:"hx-post=/validate,hx-trigger=blur,type=email"`
+	}
+
+	buf := new(bytes.Buffer)
+
+	if err := NewEncoder(buf, nil, nil).Encode(&test{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := `<input name="Email" id="Email" value="" hx-post="/validate" hx-trigger="blur" type="email"/>`
+
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("Expected extra attributes in output, got: %s", buf.String())
+	}
+}
+
+type labelErrorDecorator struct {
+	nilDecorator
+}
+
+func (labelErrorDecorator) LabelError(n *html.Node, field StructField) {
+	AppendClass(n, "text-danger")
+}
+
+func TestHTMLEncoder_SetErrorInLabel(t *testing.T) {
+	type test struct {
+		Email string
+	}
+
+	store := NewMemoryValidationStore()
+
+	for _, message := range []string{"Enter a valid email address", "Email is too long"} {
+		if err := store.AddValidationError("Email", ValidationError{Error: message, Value: "foo"}); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	m := NewEncoder(buf, nil, labelErrorDecorator{})
+	m.SetValidationStore(store)
+	m.SetErrorInLabel(true)
+
+	if err := m.Encode(&test{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := `<label for="Email">Email<span id="Email-errors" class="text-danger">Enter a valid email address</span></label>`
+
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("Expected validation error within the label, got: %s", buf.String())
+	}
+
+	if strings.Contains(buf.String(), "Email is too long") {
+		t.Errorf("Expected only the first validation error to be rendered, got: %s", buf.String())
+	}
+}
+
+func TestHTMLEncoder_OmitEmpty(t *testing.T) {
+	type test struct {
+		Name     string
+		Nickname string   `omitempty:"true"`
+		Website  string   `omitempty:"true"`
+		Tags     []string `omitempty:"true"`
+	}
+
+	buf := new(bytes.Buffer)
+
+	if err := NewEncoder(buf, nil, nil).Encode(&test{Website: "https://example.com"}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	for _, expected := range []string{`name="Name"`, `name="Website" id="Website" value="https://example.com"`} {
+		if !strings.Contains(b, expected) {
+			t.Errorf("Expected %s in output, got: %s", expected, b)
+		}
+	}
+
+	for _, unexpected := range []string{"Nickname", "Tags"} {
+		if strings.Contains(b, unexpected) {
+			t.Errorf("Expected empty %s not to be rendered, got: %s", unexpected, b)
+		}
+	}
+}
+
+func TestHTMLEncoder_Precision(t *testing.T) {
+	type test struct {
+		Price    float64 `precision:"2"`
+		Quantity float64 `precision:"0"`
+		Weight   float64 `precision:"3" step:"0.5"`
+		Ratio    float64
+	}
+
+	in := test{Price: 19.9, Quantity: 3, Weight: 1.5, Ratio: 0.125}
+
+	buf := new(bytes.Buffer)
+
+	if err := NewEncoder(buf, nil, nil).Encode(&in); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	for _, expected := range []string{
+		`<input type="number" name="Price" id="Price" value="19.90" step="0.01"/>`,
+		`<input type="number" name="Quantity" id="Quantity" value="3" step="1"/>`,
+		`<input type="number" name="Weight" id="Weight" value="1.500" step="0.5"/>`,
+		`<input type="number" name="Ratio" id="Ratio" value="0.125" step="any"/>`,
+	} {
+		if !strings.Contains(b, expected) {
+			t.Errorf("Expected %s in output, got: %s", expected, b)
+		}
+	}
+
+	var out test
+
+	if err := NewDecoder(url.Values{
+		"Price":    {"19.90"},
+		"Quantity": {"3"},
+		"Weight":   {"1.500"},
+		"Ratio":    {"0.125"},
+	}).Decode(&out); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assertEquals(t, in, out)
+}
+
+func TestHTMLEncoder_SetAllHidden(t *testing.T) {
+	type test struct {
+		Name       string
+		Age        int
+		Subscribed bool
+		Birthday   time.Time `format:"date"`
+		Foods      FoodSelect
+		Tags       []string
+		Address    Address
+		Notes      string `elem:"textarea"`
+	}
+
+	in := test{
+		Name:       "Jane",
+		Age:        42,
+		Subscribed: true,
+		Birthday:   time.Date(1980, time.March, 4, 0, 0, 0, 0, time.UTC),
+		Foods:      FoodSelect{"pizza", "beans"},
+		Tags:       []string{"a", "b"},
+		Address:    Address{AddressLine1: "1 High Street", Postcode: "AB1 2CD"},
+		Notes:      "Line one\nLine two",
+	}
+
+	buf := new(bytes.Buffer)
+	enc := NewEncoder(buf, nil, nil)
+	enc.SetAllHidden(true)
+
+	if err := enc.Encode(&in); err != nil {
+		t.Error(err)
+		return
+	}
+
+	doc, err := html.Parse(strings.NewReader(buf.String()))
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	form := make(url.Values)
+
+	var walk func(n *html.Node)
+
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if n.Data != "input" || getAttribute(n, "type") != "hidden" {
+				if n.Data != "html" && n.Data != "head" && n.Data != "body" && n.Data != "div" {
+					t.Errorf("Expected only hidden inputs, got: <%s>", n.Data)
+				}
+			} else {
+				form.Add(getAttribute(n, "name"), getAttribute(n, "value"))
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	walk(doc)
+
+	var out test
+
+	if err := NewDecoder(form).Decode(&out); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assertEquals(t, in.Name, out.Name)
+	assertEquals(t, in.Age, out.Age)
+	assertEquals(t, in.Subscribed, out.Subscribed)
+	assertEquals(t, true, in.Birthday.Equal(out.Birthday))
+	assertEquals(t, strings.Join(in.Foods, ","), strings.Join(out.Foods, ","))
+	assertEquals(t, strings.Join(in.Tags, ","), strings.Join(out.Tags, ","))
+	assertEquals(t, in.Address, out.Address)
+	assertEquals(t, in.Notes, out.Notes)
+}
+
+type fieldGroupDecorator struct {
+	nilDecorator
+}
+
+func (fieldGroupDecorator) FieldGroup(n *html.Node, group string) {
+	n.Attr = append(n.Attr, html.Attribute{Key: "class", Val: "row group-" + group})
+}
+
+func TestHTMLEncoder_GroupTag(t *testing.T) {
+	type test struct {
+		Street   string
+		City     string `group:"location"`
+		Postcode string `group:"location"`
+		Country  string
+		Secret   string `group:"hidden" show:"-"`
+	}
+
+	buf := new(bytes.Buffer)
+
+	if err := NewEncoder(buf, nil, fieldGroupDecorator{}).Encode(&test{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	expected := `<div class="row group-location"><div><label for="City">City</label><div>` +
+		`<input type="text" name="City" id="City" value=""/><div></div></div></div>` +
+		`<div><label for="Postcode">Postcode</label><div><input type="text" name="Postcode" id="Postcode" value=""/>` +
+		`<div></div></div></div></div><div><label for="Country">`
+
+	if !strings.Contains(b, expected) {
+		t.Errorf("Expected City and Postcode to be grouped, got: %s", b)
+	}
+
+	if strings.Contains(b, "group-hidden") {
+		t.Errorf("Expected empty groups to be removed, got: %s", b)
+	}
+}
+
+func TestHTMLEncoder_SetAddressSection(t *testing.T) {
+	type address struct {
+		Line1    string `autocomplete:"address-line1"`
+		PostCode string `autocomplete:"postal-code"`
+		Notes    string
+	}
+
+	type test struct {
+		Email    string `autocomplete:"email"`
+		Shipping address
+		Billing  address
+	}
+
+	buf := new(bytes.Buffer)
+	m := NewEncoder(buf, nil, nil)
+	m.SetAddressSection("Shipping", "shipping")
+	m.SetAddressSection("Billing", "billing")
+
+	if err := m.Encode(&test{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	for _, expected := range []string{
+		`<input type="text" name="Email" id="Email" value="" autocomplete="email"/>`,
+		`<input type="text" name="Shipping.Line1" id="Shipping.Line1" value="" autocomplete="section-shipping address-line1"/>`,
+		`<input type="text" name="Shipping.PostCode" id="Shipping.PostCode" value="" autocomplete="section-shipping postal-code"/>`,
+		`<input type="text" name="Shipping.Notes" id="Shipping.Notes" value=""/>`,
+		`<input type="text" name="Billing.Line1" id="Billing.Line1" value="" autocomplete="section-billing address-line1"/>`,
+		`<input type="text" name="Billing.PostCode" id="Billing.PostCode" value="" autocomplete="section-billing postal-code"/>`,
+	} {
+		if !strings.Contains(b, expected) {
+			t.Errorf("Expected %s in output, got: %s", expected, b)
+		}
+	}
+}
+
+func TestEncodeField(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	if err := EncodeField(buf, 42, `key:"Age" min:"0" help:"Your age in years"`, nil); err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := `<div><label for="Age">Age</label><div><input type="number" name="Age" id="Age" value="42" min="0" ` +
+		`aria-describedby="Age-help"/><div id="Age-help">Your age in years</div></div></div>`
+
+	assertEquals(t, expected, buf.String())
+
+	buf.Reset()
+
+	if err := EncodeField(buf, "Hello", `key:"Notes" elem:"textarea" name:"Your notes"`, nil); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !strings.Contains(buf.String(), `<label for="Notes">Your notes</label>`) ||
+		!strings.Contains(buf.String(), `<textarea name="Notes" id="Notes">Hello</textarea>`) {
+		t.Errorf("Expected a labelled textarea, got: %s", buf.String())
+	}
+
+	buf.Reset()
+
+	if err := EncodeField(buf, 1990, `key:"Person.BirthYear" name:"Year of birth"`, nil); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !strings.Contains(buf.String(), `<label for="Person.BirthYear">Year of birth</label>`) {
+		t.Errorf("Expected the name tag to be used as the label, got: %s", buf.String())
+	}
+
+	form := make(url.Values)
+
+	for _, input := range regexp.MustCompile(`<input [^>]*name="([^"]*)"[^>]*value="([^"]*)"`).FindAllStringSubmatch(buf.String(), -1) {
+		form.Add(input[1], input[2])
+	}
+
+	var out struct {
+		Person struct {
+			BirthYear int
 		}
+	}
 
-		s := &test{}
+	if err := NewDecoder(form).Decode(&out); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assertEquals(t, 1990, out.Person.BirthYear)
+
+	if err := EncodeField(buf, nil, `key:"Nothing"`, nil); !errors.Is(err, ErrNilFieldValue) {
+		t.Errorf("Expected ErrNilFieldValue, got: %v", err)
+	}
+
+	if err := EncodeField(buf, "Hello", `name:"No key"`, nil); !errors.Is(err, ErrMissingFieldKey) {
+		t.Errorf("Expected ErrMissingFieldKey, got: %v", err)
+	}
+}
+
+type pence int
+
+func (p pence) String() string {
+	return fmt.Sprintf("£%d.%02d", p/100, p%100)
+}
+
+func TestHTMLEncoder_ReadOnlyStringer(t *testing.T) {
+	type test struct {
+		Duration time.Duration `readonly:"true"`
+		Price    pence         `disabled:"true"`
+		Timeout  time.Duration
+	}
+
+	buf := new(bytes.Buffer)
+
+	if err := NewEncoder(buf, nil, nil).Encode(&test{Duration: 90 * time.Minute, Price: 1250, Timeout: time.Second}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	for _, expected := range []string{
+		`<input type="text" name="Duration" id="Duration" value="1h30m0s" readonly="readonly"/>`,
+		`<input type="text" name="Price" id="Price" value="£12.50" disabled=""/>`,
+		`<input type="number" name="Timeout" id="Timeout" value="1000000000"/>`,
+	} {
+		if !strings.Contains(b, expected) {
+			t.Errorf("Expected encoded form to contain %s, got: %s", expected, b)
+		}
+	}
+}
+
+func TestBuildSelectField(t *testing.T) {
+	var expected string
+
+	for i := 0; i < 20; i++ {
+		buf := new(bytes.Buffer)
+
+		if err := html.Render(buf, BuildSelectField(groupedSelect{"apple"}, "Produce")); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if i == 0 {
+			expected = buf.String()
+		} else if buf.String() != expected {
+			t.Errorf("Expected stable output %s, got: %s", expected, buf.String())
+			return
+		}
+	}
+
+	if strings.Index(expected, `label="Fruit"`) > strings.Index(expected, `label="Vegetables"`) {
+		t.Errorf("Expected Fruit optgroup before Vegetables, got: %s", expected)
+	}
+}
+
+func TestBuildSelectField_GroupDisabledAndTitles(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	if err := html.Render(buf, BuildSelectField(productSelect("small"), "Size")); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	for _, expected := range []string{
+		`<option value="small" title="Fits up to 2 people" selected="">Small</option>`,
+		`<option value="large">Large</option>`,
+		`<optgroup label="Discontinued" disabled=""><option value="huge" title="No longer made">Huge</option><option value="giant">Giant</option></optgroup>`,
+	} {
+		if !strings.Contains(b, expected) {
+			t.Errorf("Expected %s in output, got: %s", expected, b)
+		}
+	}
+}
+
+type productSelect string
+
+func (p productSelect) SelectMultiple() bool {
+	return false
+}
+
+func (p productSelect) SelectOptions() []Option {
+	return []Option{
+		{Value: "small", Label: "Small", Title: "Fits up to 2 people"},
+		{Value: "large", Label: "Large"},
+		{Value: "huge", Label: "Huge", Title: "No longer made", Group: OptGroup("Discontinued"), GroupDisabled: true},
+		{Value: "giant", Label: "Giant", Group: OptGroup("Discontinued")},
+	}
+}
+
+func TestHTMLEncoder_SetItemType(t *testing.T) {
+	type test struct {
+		Name  string `itemprop:"name"`
+		Email Email  `itemprop:"email"`
+		Notes string
+	}
+
+	buf := new(bytes.Buffer)
+	m := NewEncoder(buf, nil, nil)
+	m.SetItemType("https://schema.org/Person")
+
+	if err := m.Encode(&test{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	for _, expected := range []string{
+		`<div itemscope="" itemtype="https://schema.org/Person">`,
+		`<input type="text" name="Name" id="Name" value="" itemprop="name"/>`,
+		`<input type="email" name="Email" id="Email" value="" itemprop="email"/>`,
+		`<input type="text" name="Notes" id="Notes" value=""/>`,
+	} {
+		if !strings.Contains(b, expected) {
+			t.Errorf("Expected %s in output, got: %s", expected, b)
+		}
+	}
+
+	buf.Reset()
+
+	if err := m.Encode(&test{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assertEquals(t, strings.Count(buf.String(), "itemscope"), 1)
+	assertEquals(t, strings.Count(buf.String(), "itemtype"), 1)
+}
+
+func TestHTMLEncoder_SetLabelSuffix(t *testing.T) {
+	type test struct {
+		FullName string
+		Hidden   string `name:"-"`
+	}
+
+	buf := new(bytes.Buffer)
+	m := NewEncoder(buf, nil, nil)
+	m.SetLabelSuffix(":")
+
+	if err := m.Encode(&test{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	if !strings.Contains(b, `<label for="FullName">Full Name:</label>`) {
+		t.Errorf("Expected label suffix, got: %s", b)
+	}
+
+	if !strings.Contains(b, `<label for="Hidden"></label>`) {
+		t.Errorf("Expected no label suffix for empty label, got: %s", b)
+	}
+}
+
+func TestHTMLEncoder_SetHoneypot(t *testing.T) {
+	type test struct {
+		Name string
+	}
+
+	buf := new(bytes.Buffer)
+	m := NewEncoder(buf, nil, nil)
+	m.SetHoneypot("Website")
+
+	if err := m.Encode(&test{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := `<div style="position: absolute; left: -10000px;" aria-hidden="true">` +
+		`<input type="text" name="Website" value="" tabindex="-1" autocomplete="off"/></div></div>`
+
+	if !strings.HasSuffix(buf.String(), expected) {
+		t.Errorf("Expected honeypot input at the end of the form, got: %s", buf.String())
+	}
+}
+
+func TestHTMLEncoder_SetValidateTags(t *testing.T) {
+	type test struct {
+		Name  string `json:"name" required:"true"`
+		Email string `requird:"true"`
+	}
+
+	t.Run("Unknown tags are ignored by default", func(t *testing.T) {
+		if err := NewEncoder(new(bytes.Buffer), nil, nil).Encode(&test{}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Unknown tag returns an error", func(t *testing.T) {
+		m := NewEncoder(new(bytes.Buffer), nil, nil)
+		m.SetValidateTags(true, "json")
+
+		err := m.Encode(&test{})
+
+		if !errors.Is(err, ErrUnknownTag) {
+			t.Errorf("Expected ErrUnknownTag, got: %v", err)
+			return
+		}
+
+		if !strings.Contains(err.Error(), `"requird" on Email`) {
+			t.Errorf("Expected error to name the tag and field, got: %v", err)
+		}
+	})
+
+	t.Run("Tags which are not allowed return an error", func(t *testing.T) {
+		m := NewEncoder(new(bytes.Buffer), nil, nil)
+		m.SetValidateTags(true)
+
+		if err := m.Encode(&test{}); err == nil || !strings.Contains(err.Error(), `"json" on Name`) {
+			t.Errorf("Expected ErrUnknownTag for json tag, got: %v", err)
+		}
+	})
+}
+
+func TestHTMLEncoder_SetNameFormatter(t *testing.T) {
+	type test struct {
+		ID          int
+		URL         string
+		HTTPSPort   int
+		OAuth2Token string
+		Named       string `name:"Something Else"`
+	}
 
+	encode := func(nameFormatter NameFormatter) string {
 		buf := new(bytes.Buffer)
 		m := NewEncoder(buf, nil, nil)
-		m.SetFormat(true)
-		m.AddShowCondition("visible", func(field StructField) bool {
-			return true
-		})
-		m.AddShowCondition("invisible", func(field StructField) bool {
-			return false
-		})
-		m.AddGlobalShowCondition(func(field StructField) bool {
-			return field.Name != "HiddenByGlobalCondition"
+		m.SetNameFormatter(nameFormatter)
+
+		if err := m.Encode(&test{}); err != nil {
+			t.Error(err)
+		}
+
+		return buf.String()
+	}
+
+	t.Run("Default camel case names", func(t *testing.T) {
+		b := encode(nil)
+
+		for _, expected := range []string{
+			`<label for="ID">ID</label>`,
+			`<label for="URL">URL</label>`,
+			`<label for="HTTPSPort">HTTPS Port</label>`,
+			`<label for="OAuth2Token">O Auth 2 Token</label>`,
+			`<label for="Named">Something Else</label>`,
+		} {
+			if !strings.Contains(b, expected) {
+				t.Errorf("Expected %s in output, got: %s", expected, b)
+			}
+		}
+	})
+
+	t.Run("Custom name formatter", func(t *testing.T) {
+		names := map[string]string{
+			"ID":          "Identifier",
+			"URL":         "Web Address",
+			"OAuth2Token": "OAuth2 Token",
+		}
+
+		b := encode(func(fieldName string) string {
+			if name, ok := names[fieldName]; ok {
+				return name
+			}
+
+			return CamelCaseName(fieldName)
 		})
 
-		if err := m.Encode(s); err != nil {
+		for _, expected := range []string{
+			`<label for="ID">Identifier</label>`,
+			`<label for="URL">Web Address</label>`,
+			`<label for="HTTPSPort">HTTPS Port</label>`,
+			`<label for="OAuth2Token">OAuth2 Token</label>`,
+			`<label for="Named">Something Else</label>`,
+		} {
+			if !strings.Contains(b, expected) {
+				t.Errorf("Expected %s in output, got: %s", expected, b)
+			}
+		}
+	})
+}
+
+func TestHTMLEncoder_SetIDGenerator(t *testing.T) {
+	type address struct {
+		HouseName string `help:"You can leave this blank."`
+	}
+
+	type test struct {
+		Address  address
+		Agree    bool
+		Toppings toppings
+	}
+
+	buf := new(bytes.Buffer)
+	m := NewEncoder(buf, nil, nil)
+	m.SetIDGenerator(func(fieldKey string) string {
+		return "checkout-" + strings.ReplaceAll(fieldKey, ".", "-")
+	})
+
+	if err := m.Encode(&test{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	for _, expected := range []string{
+		`<label for="checkout-Address-HouseName">House Name</label>`,
+		`<input type="text" name="Address.HouseName" id="checkout-Address-HouseName" value="" aria-describedby="checkout-Address-HouseName-help"/>`,
+		`<div id="checkout-Address-HouseName-help">You can leave this blank.</div>`,
+		`<label for="checkout-Agree">Agree</label>`,
+		`<input type="checkbox" name="Agree" id="checkout-Agree"/>`,
+		`<div id="checkout-Toppings">`,
+		`<label for="checkout-Toppings0">Cheese</label><input type="checkbox" value="cheese" id="checkout-Toppings0" name="Toppings"/>`,
+	} {
+		if !strings.Contains(b, expected) {
+			t.Errorf("Expected %s in output, got: %s", expected, b)
+		}
+	}
+}
+
+func TestHTMLEncoder_SetTranslator(t *testing.T) {
+	type address struct {
+		City string `help:"The city you live in"`
+	}
+
+	type test struct {
+		Address  address
+		Priority priority
+		Toppings toppings
+		Notes    string
+	}
+
+	translations := map[string]string{
+		"Address":                 "Adresse",
+		"Address.City":            "Stadt",
+		"Address.City.help":       "Die Stadt, in der Sie wohnen",
+		"Priority.options.1":      "Niedrig",
+		"Toppings.options.cheese": "Käse",
+	}
+
+	var keys []string
+
+	buf := new(bytes.Buffer)
+	m := NewEncoder(buf, nil, nil)
+	m.SetTranslator(func(key, defaultText string) string {
+		keys = append(keys, key)
+
+		if translation, ok := translations[key]; ok {
+			return translation
+		}
+
+		return defaultText
+	})
+
+	if err := m.Encode(&test{Priority: 1}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	for _, expected := range []string{
+		`<legend>Adresse</legend>`,
+		`<label for="Address.City">Stadt</label>`,
+		`<div id="Address.City-help">Die Stadt, in der Sie wohnen</div>`,
+		`<option value="1" selected="">Niedrig</option>`,
+		`<option value="2">Medium</option>`,
+		`<label for="Toppings0">Käse</label>`,
+		`<label for="Toppings1">Ham</label>`,
+		`<label for="Notes">Notes</label>`,
+	} {
+		if !strings.Contains(b, expected) {
+			t.Errorf("Expected %s in output, got: %s", expected, b)
+		}
+	}
+
+	for _, key := range keys {
+		if key == "Notes.help" {
+			t.Errorf("Expected empty help text not to be translated")
+		}
+	}
+}
+
+func TestHTMLEncoder_SetFieldOrder(t *testing.T) {
+	type test struct {
+		Name         string
+		AddressLine1 string
+		AddressLine2 string
+		PostCode     string
+	}
+
+	encode := func(hideUnlisted bool) string {
+		buf := new(bytes.Buffer)
+		m := NewEncoder(buf, nil, nil)
+		m.SetFieldOrder([]string{"PostCode", "Name", "Unknown"})
+		m.SetHideUnlistedFields(hideUnlisted)
+
+		if err := m.Encode(&test{}); err != nil {
 			t.Error(err)
 		}
 
-		b := buf.String()
+		return buf.String()
+	}
 
-		if !strings.Contains(b, "AddressLine1") {
-			t.Fail()
+	t.Run("Unlisted fields are rendered after listed fields", func(t *testing.T) {
+		b := encode(false)
+
+		var positions []int
+
+		for _, name := range []string{"PostCode", "Name", "AddressLine1", "AddressLine2"} {
+			positions = append(positions, strings.Index(b, `name="`+name+`"`))
 		}
 
-		if strings.Contains(b, "PostCode") {
-			t.Fail()
+		for i, position := range positions {
+			if position < 0 || (i > 0 && position < positions[i-1]) {
+				t.Errorf("Expected fields in order PostCode, Name, AddressLine1, AddressLine2, got: %s", b)
+				return
+			}
 		}
+	})
 
-		if strings.Contains(b, "AddressLine2") {
-			t.Fail()
+	t.Run("Unlisted fields are hidden", func(t *testing.T) {
+		b := encode(true)
+
+		if strings.Index(b, `name="PostCode"`) > strings.Index(b, `name="Name"`) {
+			t.Errorf("Expected PostCode before Name, got: %s", b)
 		}
 
-		if strings.Contains(b, "HiddenByGlobalCondition") {
-			t.Fail()
+		if strings.Contains(b, `name="AddressLine1"`) || strings.Contains(b, `name="AddressLine2"`) {
+			t.Errorf("Expected unlisted fields to be hidden, got: %s", b)
 		}
 	})
 }
 
-type numberIndexedSelect []int
+func TestHTMLEncoder_OrderTag(t *testing.T) {
+	type Contact struct {
+		Email string
+		Phone string `order:"1"`
+	}
 
-func (n numberIndexedSelect) SelectMultiple() bool {
-	return true
+	type test struct {
+		Contact
+		Notes     string
+		Name      string `order:"1"`
+		Nickname  string `order:"2"`
+		Title     string `order:"1"`
+		Reference string `order:"-1"`
+	}
+
+	buf := new(bytes.Buffer)
+
+	if err := NewEncoder(buf, nil, nil).Encode(&test{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	b := buf.String()
+
+	var positions []int
+
+	expectedOrder := []string{"Reference", "Name", "Title", "Nickname", "Contact.Phone", "Contact.Email", "Notes"}
+
+	for _, name := range expectedOrder {
+		positions = append(positions, strings.Index(b, `name="`+name+`"`))
+	}
+
+	for i, position := range positions {
+		if position < 0 || (i > 0 && position < positions[i-1]) {
+			t.Errorf("Expected fields in order %v, got: %s", expectedOrder, b)
+			return
+		}
+	}
 }
 
-func (n numberIndexedSelect) SelectOptions() []Option {
-	return []Option{
-		{
-			Value: 0,
-			Label: "Zero",
-		},
-		{
-			Value: 1,
-			Label: "One",
-		},
-		{
-			Value: 2,
-			Label: "Two",
-		},
+func BenchmarkHTMLEncoder_Encode(b *testing.B) {
+	details := &YourDetails{
+		Name:    "Jane Doe",
+		Age:     40,
+		Address: &Address{HouseName: "Fake House"},
+	}
+
+	for i := 0; i < b.N; i++ {
+		m := NewEncoder(ioutil.Discard, nil, nil)
+
+		if err := m.Encode(details); err != nil {
+			b.Error(err)
+		}
 	}
 }
@@ -1,29 +1,128 @@
 package formulate
 
 import (
+	"html/template"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/fatih/camelcase"
+	"golang.org/x/net/html"
 )
 
+// knownTags are the struct tag keys used by formulate. See HTMLEncoder.SetValidateTags.
+var knownTags = map[string]bool{
+	"accept":       true,
+	"accesskey":    true,
+	"attr":         true,
+	"autocomplete": true,
+	"cols":         true,
+	"computed":     true,
+	"default":      true,
+	"disabled":     true,
+	"display":      true,
+	"elem":         true,
+	"format":       true,
+	"group":        true,
+	"help":         true,
+	"helphtml":     true,
+	"hint":         true,
+	"inputmode":    true,
+	"itemprop":     true,
+	"max":          true,
+	"min":          true,
+	"name":         true,
+	"omitempty":    true,
+	"order":        true,
+	"pattern":      true,
+	"placeholder":  true,
+	"precision":    true,
+	"readonly":     true,
+	"required":     true,
+	"requiredwhen": true,
+	"rows":         true,
+	"show":         true,
+	"step":         true,
+	"template":     true,
+	"type":         true,
+	"tz":           true,
+	"validators":   true,
+}
+
 // StructField is a wrapper around the reflect.StructField type. The rendering behavior of form elements is controlled
 // by Struct Tags. The following tags are currently available:
 //
 //   - name (e.g. name:"Phone Number") - this overwrites the name used in the label. This value can be left empty.
 //   - help (e.g. help:"Enter your phone number, including area code") - this text is displayed alongside the input field as a prompt.
+//   - helphtml (e.g. helphtml:"See our <a href=\"/privacy\">privacy policy</a>") - the same as help, but the text is
+//     rendered as HTML rather than being escaped. helphtml takes precedence over help. Only use helphtml with trusted text.
+//   - hint (e.g. hint:"optional") - a brief hint which is displayed in a <small> element within the field's label.
 //   - show (e.g. show:"adminOnly") - controls visibility of elements. See HTMLEncoder.AddShowCondition for more details.
 //     If "contents" is used, the field is shown and the parent fieldset (if any) will be omitted.
 //     If "fieldset" is used, anonymous structs will be built as fieldsets too, if their name is also set.
-//   - type (e.g. type:"tel", type:"hidden") - sets the HTML input "type" attribute. type:"hidden" will be rendered without labels and help text.
+//   - type (e.g. type:"tel", type:"hidden", type:"search") - sets the HTML input "type" attribute. type:"hidden" will be rendered without labels and help text.
+//     Number and time fields with type:"text" are formatted using the HTMLEncoder's Locale, if one is set.
+//   - format (e.g. format:"date") - the input used for time.Time fields. One of "datetime-local" (the default),
+//     "date", "time", "month" or "week". See also the Month and Week types.
+//   - tz (e.g. tz:"Europe/London") - the location which time.Time fields are displayed and parsed in.
+//     See also HTMLEncoder.SetTimeLocation and HTTPDecoder.SetTimeLocation.
+//   - inputmode (e.g. inputmode:"numeric") - hints at the type of virtual keyboard to use for text and number inputs.
 //   - elem (elem:"textarea") - used to specify that a text input should use a <textarea> rather than an input field.
+//     The size of a textarea can be set with the rows (e.g. rows:"10") and cols (e.g. cols:"80") tags.
+//     elem:"map" can be used on maps with string keys to render an input for each entry, rather than a JSON <textarea>.
+//     elem:"repeat" can be used on slices to render each element as a repeatable group, which client side scripts can
+//     add and remove. See HTMLEncoder.SetCollectionRenderPolicy.
 //   - min (e.g. min:"0") - minimum value for number inputs, minimum length for text inputs
 //   - max (e.g. max:"10") - maximum value for number inputs, maximum length for text inputs
 //   - step (e.g. step:"0.1") - step size for number inputs
+//   - precision (e.g. precision:"2") - the number of decimal places of float inputs, e.g. 19.90 rather than 19.9. If
+//     there is no step tag, the step is set to match, e.g. step="0.01". Values formatted by a Locale are not affected.
 //   - pattern (e.g. pattern:"[a-z]+" - regex pattern for text inputs
-//   - required (true/false) - adds the required attribute to the element.
+//   - accept (e.g. accept:"image/png,image/jpeg" or accept:".pdf") - the file types accepted by file inputs (type:"file").
+//   - required (true/false) - adds the required attribute to the element. The value can also be the key of a
+//     required condition (e.g. required:"whenOther"), in which case the field is only required if the condition
+//     passes. See HTMLEncoder.AddRequiredCondition.
+//   - requiredwhen (e.g. requiredwhen:"ConfirmedEmail=on") - the field is required when the form element named
+//     before the "=" has the value after it. The condition is added to the element as data-required-when and
+//     data-required-when-value attributes for client side scripts, and is enforced by the HTTPDecoder using a
+//     RequiredIfValidator.
+//   - disabled (true/false) - disables the element. Structs with disabled:"true" are rendered as a <fieldset disabled>,
+//     which disables all of the elements within it, including nested structs. Disabled fields are not decoded.
+//   - readonly (true/false) - adds the readonly attribute to the element, and a hidden mirror of its value. The value
+//     is still submitted, but as it could have been modified by the client, read only fields are not decoded. The
+//     HTTPDecoder adds a validation error if the submitted value does not match the mirror.
 //   - placeholder (e.g. placeholder:"phone number") - indicates a placeholder for the element.
-//   - validators (e.g. "email,notempty") - which registered Validators to use.
+//   - autocomplete (e.g. autocomplete:"new-password") - sets the autocomplete attribute for text, number and time inputs.
+//   - itemprop (e.g. itemprop:"email") - sets the microdata itemprop attribute of the element. See also HTMLEncoder.SetItemType.
+//   - validators (e.g. "email,notempty") - which registered Validators to use. Validators registered with the
+//     HTMLEncoder which implement HTMLAttributeValidator also add HTML attributes to the element.
+//   - accesskey (e.g. accesskey:"s") - sets the accesskey attribute of the element. Must be a single character.
+//   - computed (e.g. computed:"GetFullName") - renders the result of the named method of the parent struct as a
+//     display-only field. The method must take no arguments and return a single value. The value of the
+//     field itself is ignored, so a zero-size type such as struct{} can be used. Computed fields are not decoded.
+//   - display (e.g. display:"FormattedAccountNumber") - renders the result of the named method of the parent struct
+//     as the value of the field's text input, in place of the field's own value. The display tag only applies to
+//     readonly or disabled fields, which are not decoded. Editable fields are rendered with their own value, so that
+//     the submitted value can be decoded.
+//   - default (e.g. default:"10") - the default value of the field, which a reset control restores.
+//     See HTMLEncoder.SetFieldResetControls. time.Time fields with default:"now" are rendered with the current time
+//     if they are zero, and are decoded as the current time (in the field's location) if the submitted value is empty.
+//   - attr (e.g. attr:"hx-post=/validate,hx-trigger=blur") - extra attributes which are added to the element, e.g.
+//     for HTMX or Alpine.js. Attributes are separated by commas, and attributes without an "=" have an empty value.
+//     Values containing commas or equals signs can be quoted with single or double quotes, e.g.
+//     attr:"hx-vals='{\"a\": 1}',x-data". Extra attributes replace any attributes of the same name set by formulate.
+//   - omitempty (true/false) - the field is not rendered if it has the zero value, e.g. for display forms. Omitted
+//     fields are not submitted, so they are left unchanged by the HTTPDecoder.
+//   - template (e.g. template:"colourPicker") - renders the field's element using the named template, in place of
+//     the element formulate would build. See HTMLEncoder.SetTemplates.
+//   - order (e.g. order:"1") - the position of the field within its struct when rendered. Fields with an order are
+//     rendered first, in ascending order (ties keep declaration order), followed by fields without an order in
+//     declaration order. Decoding is unaffected by the order.
+//   - group (e.g. group:"cityPostcode") - consecutive fields of a struct with the same group are rendered within a
+//     single wrapper <div>, e.g. to display a city and postcode side by side. The wrapper can be styled by a
+//     Decorator which implements FieldGroupDecorator.
 //
 // These can all be used in combination with one another in a struct field. A full example of the above types is:
 //
@@ -38,11 +137,161 @@ type StructField struct {
 
 	// ValidationErrors are the errors present for the StructField. They are only set on an encode.
 	ValidationErrors []ValidationError
+
+	// LabelID is the id of the <label> for the StructField. It is only set on an encode, for fields which implement
+	// CustomEncoder, so that custom elements can reference their label (e.g. with aria-labelledby).
+	LabelID string
+
+	// HelpTextID and ValidationTextID are the ids of the help text and validation text of the StructField. They are
+	// only set on an encode, for fields which are rendered in a row. Inputs reference them with aria-describedby.
+	HelpTextID       string
+	ValidationTextID string
+
+	// metadata is the cached metadata of the StructField, if any. See cachedStructFields.
+	metadata *fieldMetadata
+
+	// locale is used to format values displayed as text. It is only set on an encode. See HTMLEncoder.SetLocale.
+	locale Locale
+
+	// timeLocation is the default location of time.Time values. See HTMLEncoder.SetTimeLocation.
+	timeLocation *time.Location
+
+	// registeredValidators are the Validators registered with the HTMLEncoder. See HTMLEncoder.AddValidators.
+	registeredValidators map[ValidatorKey]Validator
+
+	// labelSuffix is appended to the field's label. See HTMLEncoder.SetLabelSuffix.
+	labelSuffix string
+
+	// parentDisabled is set if the field is within a disabled struct, and disabledFieldset if that struct was
+	// rendered as a <fieldset disabled>, which disables the field without it needing its own disabled attribute.
+	parentDisabled   bool
+	disabledFieldset bool
+
+	// nameFormatter formats the name of the field if it has no name tag. See HTMLEncoder.SetNameFormatter.
+	nameFormatter NameFormatter
+
+	// idGenerator generates the ids of the field's elements. See HTMLEncoder.SetIDGenerator.
+	idGenerator IDGenerator
+
+	// translator translates the text of the field, using path as the key. See HTMLEncoder.SetTranslator.
+	translator Translator
+	path       string
+
+	// requiredConditions determine whether a field with a conditional required tag is required.
+	// See HTMLEncoder.AddRequiredCondition.
+	requiredConditions RequiredConditions
+
+	// templates are used to render fields with a template tag. See HTMLEncoder.SetTemplates.
+	templates *template.Template
+
+	// resetControls is set if fields with a default tag have a reset control. See HTMLEncoder.SetFieldResetControls.
+	resetControls bool
+
+	// errorInLabel is set if the first validation error is rendered within the label. See HTMLEncoder.SetErrorInLabel.
+	errorInLabel bool
+
+	// autocompleteSection scopes the autocomplete tokens of the field. See HTMLEncoder.SetAddressSection.
+	autocompleteSection string
+
+	// parent is the value of the struct which contains the field. See StructField.Parent.
+	parent reflect.Value
+
+	// absent is set on a decode if the field was not submitted, in which case its requiredwhen condition has already
+	// been validated.
+	absent bool
+}
+
+// formatsForLocale determines if the value of the field is formatted by its Locale. Only fields which can't be
+// edited are formatted, as the HTTPDecoder can't parse formatted values.
+func (sf StructField) formatsForLocale() bool {
+	return sf.locale != nil && (sf.ReadOnly() || sf.Disabled())
+}
+
+// Parent returns the value of the struct which contains the StructField, so that a ShowConditionFunc can
+// depend on the values of the field's siblings. It returns an invalid reflect.Value if the StructField is
+// not being encoded or decoded.
+//
+// On a decode, fields are decoded in order, so siblings before the field hold their submitted values and
+// siblings after it hold their existing values.
+func (sf StructField) Parent() reflect.Value {
+	return sf.parent
+}
+
+// defaultsToNow determines if the field is a time with the default:"now" tag.
+func (sf StructField) defaultsToNow() bool {
+	defaultValue, _ := sf.DefaultValue()
+
+	return defaultValue == "now"
+}
+
+// hasResetControl determines if a reset control is rendered for the field.
+func (sf StructField) hasResetControl() bool {
+	_, ok := sf.DefaultValue()
+
+	return sf.resetControls && ok
+}
+
+// IDGenerator returns the id attribute of the form element with the given key. See HTMLEncoder.SetIDGenerator.
+type IDGenerator func(fieldKey string) string
+
+// ElementID returns the id attribute of the form element with the given key. Builders and CustomEncoders should
+// use ElementID rather than the key when setting an element's id.
+func (sf StructField) ElementID(key string) string {
+	if sf.idGenerator == nil {
+		return key
+	}
+
+	return sf.idGenerator(key)
+}
+
+// translate returns the translation of defaultText, identified by the path of the field followed by suffix.
+// Empty text is not translated, so that fields without a label or help text remain without one.
+func (sf StructField) translate(suffix, defaultText string) string {
+	if sf.translator == nil || defaultText == "" {
+		return defaultText
+	}
+
+	key := sf.path
+
+	if suffix != "" {
+		key += fieldSeparator + suffix
+	}
+
+	return sf.translator(key, defaultText)
+}
+
+// optionLabel returns the (translated) label of an option of the field.
+func (sf StructField) optionLabel(opt Option) string {
+	return sf.translate("options"+fieldSeparator+toString(opt.Value), opt.Label)
+}
+
+// tag returns the value of the struct tag key, using the cached metadata if it is available.
+func (sf StructField) tag(key string) string {
+	if sf.metadata != nil {
+		return sf.metadata.tags[key]
+	}
+
+	return sf.Tag.Get(key)
+}
+
+// lookupTag returns the value of the tag key, and whether the tag is set.
+func (sf StructField) lookupTag(key string) (string, bool) {
+	if sf.metadata != nil {
+		value, ok := sf.metadata.tags[key]
+		return value, ok
+	}
+
+	return sf.Tag.Lookup(key)
+}
+
+// DefaultValue returns the value of the default tag, and whether it is set. The default value may be empty.
+func (sf StructField) DefaultValue() (string, bool) {
+	return sf.lookupTag("default")
 }
 
 // GetName returns the name of the StructField, taking into account tag name overrides.
 func (sf StructField) GetName() string {
-	tagName := sf.Tag.Get("name")
+	tagName := sf.tag("name")
 
 	if tagName == "-" {
 		return ""
@@ -52,18 +301,46 @@ func (sf StructField) GetName() string {
 		return tagName
 	}
 
-	return camelCase(sf.Name)
+	if sf.nameFormatter != nil {
+		return sf.nameFormatter(sf.Name)
+	}
+
+	return CamelCaseName(sf.Name)
 }
 
 // GetHelpText returns the help text for the field.
 func (sf StructField) GetHelpText() string {
-	return sf.Tag.Get("help")
+	return sf.tag("help")
+}
+
+// GetHelpHTML returns the HTML help text for the field. See the helphtml tag.
+func (sf StructField) GetHelpHTML() template.HTML {
+	return template.HTML(sf.tag("helphtml"))
+}
+
+// helpText returns the (translated) help text of the field, and whether it is HTML.
+func (sf StructField) helpText() (string, bool) {
+	if helpHTML := sf.GetHelpHTML(); helpHTML != "" {
+		return sf.translate("help", string(helpHTML)), true
+	}
+
+	return sf.translate("help", sf.GetHelpText()), false
+}
+
+// Template returns the name of the template used to render the field's element. See HTMLEncoder.SetTemplates.
+func (sf StructField) Template() string {
+	return sf.tag("template")
+}
+
+// GetHint returns the brief hint displayed within the label of the StructField.
+func (sf StructField) GetHint() string {
+	return sf.tag("hint")
 }
 
 // Hidden determines if a StructField is hidden based on the showConditions.
 // If multiple show conditions are specified, they must all pass for the field to be visible.
 func (sf StructField) Hidden(showConditions ShowConditions) bool {
-	showTag := sf.Tag.Get("show")
+	showTag := sf.tag("show")
 
 	if showTag == "-" {
 		return true
@@ -88,13 +365,23 @@ func (sf StructField) Hidden(showConditions ShowConditions) bool {
 	return !visible
 }
 
-func camelCase(s string) string {
-	return strings.Join(camelcase.Split(s), " ")
+// NameFormatter formats the name of a struct field for display, for fields which don't have a name tag.
+// See HTMLEncoder.SetNameFormatter.
+type NameFormatter func(fieldName string) string
+
+// Translator translates the text displayed for a field, such as its label or help text. It returns the translation
+// of defaultText, identified by key, or defaultText if there is no translation. See HTMLEncoder.SetTranslator.
+type Translator func(key, defaultText string) string
+
+// CamelCaseName is the default NameFormatter. It splits fieldName into words at camel case boundaries, e.g.
+// "HouseName" becomes "House Name". Runs of capitals are kept together, so "HTTPSPort" becomes "HTTPS Port".
+func CamelCaseName(fieldName string) string {
+	return strings.Join(camelcase.Split(fieldName), " ")
 }
 
 // InputType returns the HTML <input> element type attribute
 func (sf StructField) InputType(original string) string {
-	t := sf.Tag.Get("type")
+	t := sf.tag("type")
 
 	if t != "" {
 		return t
@@ -103,55 +390,313 @@ func (sf StructField) InputType(original string) string {
 	return original
 }
 
-// Elem returns the element to be used. Currently, the only supported value is <textarea>.
+// Elem returns the element to be used. Currently, the supported values are "textarea", "map" and "repeat".
 // <input> will be used if not specified.
 func (sf StructField) Elem() string {
-	return sf.Tag.Get("elem")
+	return sf.tag("elem")
+}
+
+// TimeFormat is the type of input used for a time.Time field, which determines the layout of its value.
+// Supported formats are "datetime-local", "date", "time", "month" and "week". If no format is specified,
+// "datetime-local" is used.
+func (sf StructField) TimeFormat() string {
+	if format := sf.tag("format"); format != "" {
+		return format
+	}
+
+	return "datetime-local"
+}
+
+// location returns the location which time.Time values of the field are displayed and parsed in.
+// The tz struct tag takes precedence over the location set on the HTMLEncoder or HTTPDecoder.
+// If neither is set, nil is returned.
+func (sf StructField) location() (*time.Location, error) {
+	if tz := sf.tag("tz"); tz != "" {
+		return time.LoadLocation(tz)
+	}
+
+	return sf.timeLocation, nil
+}
+
+// validatorAttributes returns the HTML attributes of the registered Validators used by the field
+// which implement HTMLAttributeValidator.
+func (sf StructField) validatorAttributes() []html.Attribute {
+	var attributes []html.Attribute
+
+	for _, key := range sf.Validators() {
+		if validator, ok := sf.registeredValidators[key].(HTMLAttributeValidator); ok {
+			attributes = append(attributes, validator.HTMLAttributes()...)
+		}
+	}
+
+	return attributes
+}
+
+// Rows is the number of visible text lines of a <textarea>.
+func (sf StructField) Rows() string {
+	return sf.tag("rows")
+}
+
+// Cols is the visible width of a <textarea>, in average character widths.
+func (sf StructField) Cols() string {
+	return sf.tag("cols")
 }
 
 // HasMin determines if a StructField has a minimum value
 func (sf StructField) HasMin() bool {
-	return sf.Tag.Get("min") != ""
+	return sf.tag("min") != ""
 }
 
 // Min is the minimum value of the StructField
 func (sf StructField) Min() string {
-	return sf.Tag.Get("min")
+	return sf.tag("min")
 }
 
 // HasMax determines if a StructField has a maximum value
 func (sf StructField) HasMax() bool {
-	return sf.Tag.Get("max") != ""
+	return sf.tag("max") != ""
 }
 
 // Max is the maximum value of the StructField
 func (sf StructField) Max() string {
-	return sf.Tag.Get("max")
+	return sf.tag("max")
+}
+
+// Precision returns the number of decimal places of the precision tag, and whether it is set to a valid
+// (non-negative integer) value.
+func (sf StructField) Precision() (int, bool) {
+	precision, err := strconv.Atoi(sf.tag("precision"))
+
+	if err != nil || precision < 0 {
+		return 0, false
+	}
+
+	return precision, true
+}
+
+// Group returns the value of the group tag.
+func (sf StructField) Group() string {
+	return sf.tag("group")
+}
+
+// Order returns the value of the order tag, and whether it is set to a valid integer.
+func (sf StructField) Order() (int, bool) {
+	order, err := strconv.Atoi(sf.tag("order"))
+
+	if err != nil {
+		return 0, false
+	}
+
+	return order, true
 }
 
 // HasStep determines if a StructField has a step value
 func (sf StructField) HasStep() bool {
-	return sf.Tag.Get("step") != ""
+	return sf.tag("step") != ""
 }
 
 // Step value of the StructField
 func (sf StructField) Step() string {
-	return sf.Tag.Get("step")
+	return sf.tag("step")
 }
 
 // Pattern is the regex for the input field.
 func (sf StructField) Pattern() string {
-	return sf.Tag.Get("pattern")
+	return sf.tag("pattern")
+}
+
+// ItemProp is the microdata property name of the field, e.g. a schema.org property such as "email".
+func (sf StructField) ItemProp() string {
+	return sf.tag("itemprop")
+}
+
+// Accept is the comma separated list of file types accepted by a file input.
+func (sf StructField) Accept() string {
+	return sf.tag("accept")
+}
+
+// InputMode is the inputmode attribute for the input field, e.g. "numeric", "tel" or "email".
+func (sf StructField) InputMode() string {
+	return sf.tag("inputmode")
+}
+
+// Autocomplete is the autocomplete attribute for the input field. If it is empty, no attribute is rendered and the
+// browser default applies. Common values are:
+//
+//   - "off" - disables autofill for the field.
+//   - "name", "email", "tel", "street-address", "postal-code", "country" - personal and address details.
+//   - "username" - the username of an account.
+//   - "current-password" - an existing password, e.g. on a login form.
+//   - "new-password" - a new password, e.g. on a registration or password change form.
+//   - "one-time-code" - a one-time code used for verification.
+//
+// See https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#autofill for the full list.
+//
+// If the field is within a struct with an address section (see HTMLEncoder.SetAddressSection), the value is prefixed
+// with the section token, e.g. "section-billing street-address".
+func (sf StructField) Autocomplete() string {
+	autocomplete := sf.tag("autocomplete")
+
+	switch {
+	case sf.autocompleteSection == "", autocomplete == "", autocomplete == "on", autocomplete == "off":
+		return autocomplete
+	case strings.HasPrefix(autocomplete, "section-"):
+		// the field sets its own section.
+		return autocomplete
+	default:
+		return "section-" + sf.autocompleteSection + " " + autocomplete
+	}
 }
 
 // Placeholder defines the placeholder attribute for the input field
 func (sf StructField) Placeholder() string {
-	return sf.Tag.Get("placeholder")
+	return sf.tag("placeholder")
+}
+
+// OmitEmpty indicates that the field is not rendered if it has the zero value.
+func (sf StructField) OmitEmpty() bool {
+	return sf.tag("omitempty") == "true"
 }
 
-// Required indicates that an input field must be filled in.
+// Disabled indicates that a field has the disabled:"true" tag, or is within a struct which is disabled.
+func (sf StructField) Disabled() bool {
+	return sf.parentDisabled || sf.tag("disabled") == "true"
+}
+
+// Required indicates that an input field must be filled in. If the required tag is not "true", it is treated as a
+// comma separated list of required condition keys, which must all be registered and pass for the field to be required.
 func (sf StructField) Required() bool {
-	return sf.Tag.Get("required") == "true"
+	requiredTag := sf.tag("required")
+
+	if requiredTag == "true" {
+		return true
+	}
+
+	if requiredTag == "" || requiredTag == "false" {
+		return false
+	}
+
+	for _, tag := range strings.Split(requiredTag, ",") {
+		conditionFuncs, ok := sf.requiredConditions[tag]
+
+		if !ok || len(conditionFuncs) == 0 {
+			return false
+		}
+
+		for _, fn := range conditionFuncs {
+			if !fn(sf) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// RequiredWhen returns the condition of the requiredwhen tag: the form element name of the field it depends on, and
+// the value of that field which makes this field required. ok is false if the tag is not set or is not of the form
+// "field=value". Whitespace around the field and value is ignored.
+func (sf StructField) RequiredWhen() (field, value string, ok bool) {
+	condition := sf.tag("requiredwhen")
+
+	i := strings.Index(condition, "=")
+
+	if i < 0 {
+		return "", "", false
+	}
+
+	field = strings.TrimSpace(condition[:i])
+	value = strings.TrimSpace(condition[i+1:])
+
+	if field == "" || strings.ContainsAny(field, " \t\n") {
+		return "", "", false
+	}
+
+	return field, value, true
+}
+
+// Computed is the name of the method whose result is displayed in place of the field's value.
+func (sf StructField) Computed() string {
+	return sf.tag("computed")
+}
+
+// DisplayMethod is the name of the method whose result is used as the value of the field's input. It is only used
+// if the field is ReadOnly or Disabled.
+func (sf StructField) DisplayMethod() string {
+	return sf.tag("display")
+}
+
+// ReadOnly indicates that an input field cannot be changed.
+func (sf StructField) ReadOnly() bool {
+	return sf.tag("readonly") == "true"
+}
+
+// ExtraAttributes returns the attributes of the attr tag, in the order they are given.
+func (sf StructField) ExtraAttributes() []html.Attribute {
+	return parseExtraAttributes(sf.tag("attr"))
+}
+
+// parseExtraAttributes parses a comma separated list of attributes of the form key=value. Values may be quoted with
+// single or double quotes, within which commas and equals signs are not treated as separators.
+func parseExtraAttributes(s string) []html.Attribute {
+	var (
+		attrs    []html.Attribute
+		current  strings.Builder
+		key      string
+		hasValue bool
+		quote    rune
+	)
+
+	appendAttr := func() {
+		value := current.String()
+
+		if !hasValue {
+			key, value = value, ""
+		}
+
+		if key = strings.TrimSpace(key); key != "" {
+			attrs = append(attrs, html.Attribute{Key: key, Val: value})
+		}
+
+		current.Reset()
+		key, hasValue = "", false
+	}
+
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case hasValue && (r == '"' || r == '\''):
+			quote = r
+		case r == '=' && !hasValue:
+			key, hasValue = current.String(), true
+			current.Reset()
+		case r == ',':
+			appendAttr()
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	appendAttr()
+
+	return attrs
+}
+
+// AccessKey is the keyboard shortcut for the input field. Only single characters are valid access keys,
+// any other value is ignored and an empty string is returned.
+func (sf StructField) AccessKey() string {
+	accessKey := sf.tag("accesskey")
+
+	if utf8.RuneCountInString(accessKey) != 1 {
+		return ""
+	}
+
+	return accessKey
 }
 
 func (sf StructField) IsExported() bool {
@@ -162,7 +707,7 @@ func (sf StructField) IsExported() bool {
 // show:"contents" to indicate that a fieldset should not be built for this struct. Use show:"fieldset"
 // to indicate that anonymous structs should be built in a fieldset.
 func (sf StructField) BuildFieldset() bool {
-	showTag := sf.Tag.Get("show")
+	showTag := sf.tag("show")
 
 	for _, tag := range strings.Split(showTag, ",") {
 		if tag == "contents" {
@@ -178,7 +723,15 @@ func (sf StructField) BuildFieldset() bool {
 
 // Validators are the TagNames of the registered Validators. Multiple Validators may be specified, separated by a comma.
 func (sf StructField) Validators() []ValidatorKey {
-	split := strings.Split(sf.Tag.Get("validators"), ",")
+	if sf.metadata != nil {
+		return sf.metadata.validators
+	}
+
+	return parseValidators(sf.tag("validators"))
+}
+
+func parseValidators(tag string) []ValidatorKey {
+	split := strings.Split(tag, ",")
 
 	var keys []ValidatorKey
 
@@ -210,6 +763,19 @@ type ShowConditions map[string][]ShowConditionFunc
 //
 // You can add multiple ShowConditions for the same key.
 //
+// ShowConditionFuncs can also depend on the other fields of the same struct, using StructField.Parent. Given:
+//
+//	type Delivery struct {
+//	  Method       string
+//	  Instructions string `show:"courierOnly"`
+//	}
+//
+// The Instructions field can be shown only for courier deliveries:
+//
+//	AddShowCondition("courierOnly", func(field StructField) bool {
+//	   return field.Parent().FieldByName("Method").String() == "courier"
+//	})
+//
 // It is also possible to add ShowConditionFuncs to be used on every StructField. See AddGlobalShowCondition.
 //
 // Note: ShowConditions should be added to both the Encoder and Decoder.
@@ -218,9 +784,45 @@ func (s ShowConditions) AddShowCondition(key string, fn ShowConditionFunc) {
 }
 
 // AddGlobalShowCondition adds a ShowConditionFunc to be called on all StructFields.
+//
+// Note: like keyed ShowConditions, global ShowConditions should be added identically to both the Encoder and Decoder.
+// The HTTPDecoder does not decode fields which are hidden, so a field which is hidden by the Encoder but not the
+// Decoder could still be set by a crafted form submission.
 func (s ShowConditions) AddGlobalShowCondition(fn ShowConditionFunc) {
 	s[showConditionAllFields] = append(s[showConditionAllFields], fn)
 }
 
+// RequiredConditionFunc is a function which determines whether a form element is required.
+// See: HTMLEncoder.AddRequiredCondition
+type RequiredConditionFunc func(field StructField) bool
+
+// RequiredConditions maps the keys used in required struct tags to the RequiredConditionFuncs which must all pass
+// for a field to be required. See: HTMLEncoder.AddRequiredCondition
+type RequiredConditions map[string][]RequiredConditionFunc
+
+// AddRequiredCondition allows you to determine whether certain form elements are required.
+// For example, given the following struct:
+//
+//	type Example struct {
+//	  Choice      string
+//	  OtherChoice string `required:"whenOther"`
+//	}
+//
+// If you wanted to make the OtherChoice field required only when "Other" is chosen, you would call
+// AddRequiredCondition as follows:
+//
+//	AddRequiredCondition("whenOther", func(field StructField) bool {
+//	   return example.Choice == "Other"
+//	})
+//
+// You can add multiple RequiredConditions for the same key, and they must all pass for the field to be required.
+// A field with a required tag which references a key with no RequiredConditions is not required.
+//
+// Note: the HTTPDecoder does not validate required fields, so a Validator should be used to enforce the condition
+// when the form is submitted.
+func (r RequiredConditions) AddRequiredCondition(key string, fn RequiredConditionFunc) {
+	r[key] = append(r[key], fn)
+}
+
 // showConditionAllFields is a special key for a ShowConditionFunc that is used on all fields.
 const showConditionAllFields = "*"
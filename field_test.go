@@ -1 +1,25 @@
 package formulate
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestStructField_ExtraAttributes(t *testing.T) {
+	for tag, expected := range map[string][]html.Attribute{
+		``:                                       nil,
+		`hx-post=/validate,hx-trigger=blur`:      {{Key: "hx-post", Val: "/validate"}, {Key: "hx-trigger", Val: "blur"}},
+		`x-data, hx-boost=true`:                  {{Key: "x-data"}, {Key: "hx-boost", Val: "true"}},
+		`hx-vals='{"a": 1, "b": "c=d"}',x-cloak`: {{Key: "hx-vals", Val: `{"a": 1, "b": "c=d"}`}, {Key: "x-cloak"}},
+		`data-expr="a,b",data-eq=x=y`:            {{Key: "data-expr", Val: "a,b"}, {Key: "data-eq", Val: "x=y"}},
+	} {
+		field := StructField{StructField: reflect.StructField{Tag: reflect.StructTag(`attr:` + strconv.Quote(tag))}}
+
+		if attrs := field.ExtraAttributes(); !reflect.DeepEqual(expected, attrs) {
+			t.Errorf("%s: expected %v, got %v", tag, expected, attrs)
+		}
+	}
+}
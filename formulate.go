@@ -2,6 +2,7 @@ package formulate
 
 import (
 	"bytes"
+	"errors"
 	"html/template"
 	"io"
 	"net/http"
@@ -24,11 +25,11 @@ func Formulate(r *http.Request, data interface{}, encoderBuilder HTMLEncoderBuil
 		decoder := decoderBuilder(r, r.Form)
 		decoder.SetValidationStore(validationStore)
 
-		err := decoder.Decode(data)
+		err := decoder.DecodeContext(r.Context(), data)
 
 		if err == nil {
 			passedValidation = true
-		} else if err != ErrFormFailedValidation {
+		} else if !errors.Is(err, ErrFormFailedValidation) {
 			return "", passedValidation, err
 		}
 	}
@@ -38,7 +39,7 @@ func Formulate(r *http.Request, data interface{}, encoderBuilder HTMLEncoderBuil
 	encoder := encoderBuilder(r, buf)
 	encoder.SetValidationStore(validationStore)
 
-	if err := encoder.Encode(data); err != nil {
+	if err := encoder.EncodeContext(r.Context(), data); err != nil {
 		return "", passedValidation, err
 	}
 
@@ -46,7 +47,7 @@ func Formulate(r *http.Request, data interface{}, encoderBuilder HTMLEncoderBuil
 }
 
 // HTMLEncoderBuilder is a function that builds a HTMLEncoder given an io.Writer as the output.
-// When used with the Formulate method, this allows for custom building of the encoder (including ShowConditions etc).
+// When used with the Formulate method, this allows for custom building of the encoder (including ShowConditions, Validators etc).
 type HTMLEncoderBuilder func(r *http.Request, w io.Writer) *HTMLEncoder
 
 // HTTPDecoderBuilder is a function that builds a HTTPDecoder given the form as the input.
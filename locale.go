@@ -0,0 +1,73 @@
+package formulate
+
+import (
+	"strings"
+	"time"
+)
+
+// Locale formats numbers and dates for display. A Locale can be set on the HTMLEncoder with SetLocale.
+//
+// Only values which are displayed as text in fields which can't be edited are formatted, i.e. fields with the struct
+// tag type:"text" which are also read only or disabled. The HTTPDecoder parses machine readable values, so editable
+// fields and inputs which require machine readable values (e.g. <input type="number"> and
+// <input type="datetime-local">) are never formatted. Locale can be implemented using a package such as
+// golang.org/x/text to support the formats of many languages.
+type Locale interface {
+	// FormatNumber formats an integer or floating point number.
+	FormatNumber(n interface{}) string
+	// FormatTime formats a time.Time.
+	FormatTime(t time.Time) string
+}
+
+// BasicLocale is a Locale which formats numbers using the given separators, and times using the TimeLayout.
+type BasicLocale struct {
+	// DecimalSeparator separates the integer and fractional parts of a number, e.g. "." or ",".
+	DecimalSeparator string
+	// GroupSeparator separates each group of thousands in a number, e.g. "," or ".". It may be empty.
+	GroupSeparator string
+	// TimeLayout is the layout used to format times, see time.Time.Format. If empty, "2006-01-02 15:04" is used.
+	TimeLayout string
+}
+
+// FormatNumber implements the Locale interface.
+func (l BasicLocale) FormatNumber(n interface{}) string {
+	s := toString(n)
+
+	var sign string
+
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+
+	integer, fraction := s, ""
+
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		integer, fraction = s[:i], s[i+1:]
+	}
+
+	if l.GroupSeparator != "" {
+		var groups []string
+
+		for len(integer) > 3 {
+			groups = append([]string{integer[len(integer)-3:]}, groups...)
+			integer = integer[:len(integer)-3]
+		}
+
+		integer = strings.Join(append([]string{integer}, groups...), l.GroupSeparator)
+	}
+
+	if fraction != "" {
+		integer += l.DecimalSeparator + fraction
+	}
+
+	return sign + integer
+}
+
+// FormatTime implements the Locale interface.
+func (l BasicLocale) FormatTime(t time.Time) string {
+	if l.TimeLayout == "" {
+		return t.Format("2006-01-02 15:04")
+	}
+
+	return t.Format(l.TimeLayout)
+}
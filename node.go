@@ -23,6 +23,17 @@ func AppendClass(n *html.Node, classes ...string) {
 	})
 }
 
+// getAttribute returns the value of the attribute named attr, or an empty string if n does not have the attribute.
+func getAttribute(n *html.Node, attr string) string {
+	for _, a := range n.Attr {
+		if a.Key == attr {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
 // HasAttribute returns true if n has the attribute named attr.
 func HasAttribute(n *html.Node, attr string) bool {
 	for _, a := range n.Attr {
@@ -33,3 +44,31 @@ func HasAttribute(n *html.Node, attr string) bool {
 
 	return false
 }
+
+// setAttribute sets the value of the attribute named attr, adding it to n if it does not exist.
+func setAttribute(n *html.Node, attr, val string) {
+	for i, a := range n.Attr {
+		if a.Key == attr {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+
+	n.Attr = append(n.Attr, html.Attribute{
+		Key: attr,
+		Val: val,
+	})
+}
+
+// removeAttribute removes all attributes named attr from n.
+func removeAttribute(n *html.Node, attr string) {
+	attrs := n.Attr[:0]
+
+	for _, a := range n.Attr {
+		if a.Key != attr {
+			attrs = append(attrs, a)
+		}
+	}
+
+	n.Attr = attrs
+}
@@ -0,0 +1,117 @@
+//go:build go1.21
+// +build go1.21
+
+package formulate
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// Optional is a generic optional value, which implements OptionalValue.
+type Optional[T any] struct {
+	value T
+	valid bool
+}
+
+func (o Optional[T]) IsOptional() bool {
+	return true
+}
+
+func (o Optional[T]) GetValue() (T, bool) {
+	return o.value, o.valid
+}
+
+func (o *Optional[T]) SetValue(value T) {
+	o.value = value
+	o.valid = true
+}
+
+type optionalTest struct {
+	Age      Optional[int]
+	Nickname Optional[string]
+}
+
+func TestOptionalValue(t *testing.T) {
+	t.Run("Decoding present and absent values", func(t *testing.T) {
+		var out optionalTest
+		out.Nickname.SetValue("unchanged")
+
+		dec := NewDecoder(url.Values{
+			"Age": {"42"},
+		})
+
+		if err := dec.Decode(&out); err != nil {
+			t.Error(err)
+			return
+		}
+
+		age, ok := out.Age.GetValue()
+		assertEquals(t, true, ok)
+		assertEquals(t, 42, age)
+
+		nickname, ok := out.Nickname.GetValue()
+		assertEquals(t, true, ok)
+		assertEquals(t, "unchanged", nickname)
+	})
+
+	t.Run("Decoding an empty value resets the optional", func(t *testing.T) {
+		var out optionalTest
+		out.Age.SetValue(42)
+
+		dec := NewDecoder(url.Values{
+			"Age": {""},
+		})
+
+		if err := dec.Decode(&out); err != nil {
+			t.Error(err)
+			return
+		}
+
+		_, ok := out.Age.GetValue()
+		assertEquals(t, false, ok)
+	})
+
+	t.Run("Encoding present and absent values", func(t *testing.T) {
+		var in optionalTest
+		in.Nickname.SetValue("Bob")
+
+		buf := new(bytes.Buffer)
+
+		if err := NewEncoder(buf, nil, nil).Encode(&in); err != nil {
+			t.Error(err)
+			return
+		}
+
+		b := buf.String()
+
+		if !strings.Contains(b, `<input type="number" name="Age" id="Age" value=""/>`) {
+			t.Errorf("Expected absent Age to be rendered with an empty value, got: %s", b)
+		}
+
+		if !strings.Contains(b, `<input type="text" name="Nickname" id="Nickname" value="Bob"/>`) {
+			t.Errorf("Expected present Nickname to be rendered, got: %s", b)
+		}
+	})
+
+	t.Run("Round trip", func(t *testing.T) {
+		var in optionalTest
+		in.Nickname.SetValue("Bob")
+
+		form := url.Values{
+			"Age":      {""},
+			"Nickname": {"Bob"},
+		}
+
+		var out optionalTest
+
+		if err := NewDecoder(form).Decode(&out); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, in, out)
+	})
+}
@@ -0,0 +1,651 @@
+package formulate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidSchemaType is returned by JSONSchema when it is not given a struct or a pointer to a struct.
+var ErrInvalidSchemaType = errors.New("formulate: JSONSchema requires a struct or a pointer to a struct")
+
+// ErrInvalidJSONSchema is returned by HTTPDecoder.SetJSONSchema when the schema can't be parsed, uses a keyword
+// which is not supported, or has an invalid pattern.
+var ErrInvalidJSONSchema = errors.New("formulate: invalid JSON schema")
+
+// jsonSchema is a subset of a JSON Schema (https://json-schema.org), describing the fields of a form.
+type jsonSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Type        string                 `json:"type,omitempty"`
+	Format      string                 `json:"format,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Properties  map[string]*jsonSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	Items       *jsonSchema            `json:"items,omitempty"`
+	Enum        []interface{}          `json:"enum,omitempty"`
+	Minimum     *float64               `json:"minimum,omitempty"`
+	Maximum     *float64               `json:"maximum,omitempty"`
+	MultipleOf  *float64               `json:"multipleOf,omitempty"`
+	MinLength   *int                   `json:"minLength,omitempty"`
+	MaxLength   *int                   `json:"maxLength,omitempty"`
+	Pattern     string                 `json:"pattern,omitempty"`
+	ReadOnly    bool                   `json:"readOnly,omitempty"`
+
+	// pattern is the compiled Pattern. It is only set on schemas which are compiled by compileSchema.
+	pattern *regexp.Regexp
+}
+
+// timeSchemaPatterns maps the format struct tag of time.Time fields to patterns matching the values of their inputs.
+// Only "date" values are valid for a JSON Schema format; the "date-time" and "time" formats require an offset, which
+// the local time inputs don't have.
+var timeSchemaPatterns = map[string]string{
+	"datetime-local": `^[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}$`,
+	"time":           `^[0-9]{2}:[0-9]{2}$`,
+	"month":          `^[0-9]{4}-[0-9]{2}$`,
+	"week":           `^[0-9]{4}-W[0-9]{2}$`,
+}
+
+// JSONSchema generates a JSON Schema describing the form which would be rendered for i, which must be a struct
+// or a pointer to a struct. The schema is built from the same StructField accessors as the HTMLEncoder, so
+// field names, help text, min, max, step, pattern and required tags are reflected in the schema. The options of
+// Select, RadioList and CheckboxGroup fields are listed as enum values. Show conditions are not evaluated, so all exported fields
+// are included in the schema.
+func JSONSchema(i interface{}) ([]byte, error) {
+	v := reflect.ValueOf(i)
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v = reflect.New(v.Type().Elem())
+		}
+
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil, ErrInvalidSchemaType
+	}
+
+	schema := buildSchema(v, StructField{})
+	schema.Schema = "https://json-schema.org/draft/2020-12/schema"
+
+	return json.Marshal(schema)
+}
+
+// buildSchema builds the schema for the value v of the given field.
+func buildSchema(v reflect.Value, field StructField) *jsonSchema {
+	schema := &jsonSchema{
+		Title:       field.GetName(),
+		Description: field.GetHelpText(),
+		ReadOnly:    field.ReadOnly(),
+	}
+
+	if field.Name == "" {
+		schema.Title = v.Type().Name()
+	}
+
+	if isNullableSelect(v.Type()) {
+		if v.IsNil() {
+			v = reflect.New(v.Type().Elem())
+		}
+
+		schema = buildSchema(v.Elem(), field)
+
+		if schema.Enum != nil {
+			// the empty option of a nullable select is decoded as nil.
+			schema.Enum = append(schema.Enum, nil)
+		}
+
+		return schema
+	}
+
+	if v.CanInterface() {
+		switch a := v.Interface().(type) {
+		case time.Time:
+			schema.Type = "string"
+
+			if field.TimeFormat() == "date" {
+				schema.Format = "date"
+			} else {
+				schema.Pattern = timeSchemaPatterns[field.TimeFormat()]
+			}
+
+			return schema
+		case big.Rat:
+			schema.Type = "string"
+			return schema
+		case Flags:
+			schema.Type = "array"
+			schema.Items = &jsonSchema{Enum: optionValues(flagsSelect{flags: a.FlagOptions()}.SelectOptions())}
+
+			return schema
+		case Select:
+			enum := optionValues(a.SelectOptions())
+
+			if a.SelectMultiple() {
+				schema.Type = "array"
+				schema.Items = &jsonSchema{Enum: enum}
+			} else {
+				schema.Enum = enum
+			}
+
+			return schema
+		case RadioList:
+			schema.Enum = optionValues(a.RadioOptions())
+
+			return schema
+		case CheckboxGroup:
+			schema.Type = "array"
+			schema.Items = &jsonSchema{Enum: optionValues(a.CheckboxOptions())}
+
+			return schema
+		case CustomEncoder:
+			// the value of a CustomEncoder can't be described without knowing how it will be decoded.
+			return schema
+		}
+
+		if isTextType(v.Type()) {
+			schema.Type = "string"
+			return schema
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return buildSchema(reflect.New(v.Type().Elem()).Elem(), field)
+		}
+
+		return buildSchema(v.Elem(), field)
+	case reflect.Interface:
+		if v.IsNil() {
+			return schema
+		}
+
+		return buildSchema(v.Elem(), field)
+	case reflect.Struct:
+		schema.Type = "object"
+		schema.Properties = make(map[string]*jsonSchema)
+
+		for i, structField := range cachedStructFields(v.Type()) {
+			if !structField.IsExported() {
+				continue
+			}
+
+			if structField.Computed() != "" {
+				schema.Properties[structField.Name] = &jsonSchema{
+					Type:        "string",
+					Title:       structField.GetName(),
+					Description: structField.GetHelpText(),
+					ReadOnly:    true,
+				}
+
+				continue
+			}
+
+			schema.Properties[structField.Name] = buildSchema(v.Field(i), structField)
+
+			if structField.Required() {
+				schema.Required = append(schema.Required, structField.Name)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		schema.Type = "array"
+		schema.Items = buildSchema(reflect.New(v.Type().Elem()).Elem(), StructField{})
+	case reflect.Map:
+		schema.Type = "object"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		schema.Type = "integer"
+		numberConstraints(schema, field)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema.Type = "integer"
+		numberConstraints(schema, field)
+
+		if schema.Minimum == nil {
+			zero := 0.0
+			schema.Minimum = &zero
+		}
+	case reflect.Float32, reflect.Float64:
+		schema.Type = "number"
+		numberConstraints(schema, field)
+	case reflect.String:
+		schema.Type = "string"
+		schema.Pattern = field.Pattern()
+
+		if field.HasMin() {
+			if min, err := strconv.Atoi(field.Min()); err == nil {
+				schema.MinLength = &min
+			}
+		}
+
+		if field.HasMax() {
+			if max, err := strconv.Atoi(field.Max()); err == nil {
+				schema.MaxLength = &max
+			}
+		}
+
+		switch v.Type() {
+		case reflect.TypeOf(Email("")):
+			schema.Format = "email"
+		case reflect.TypeOf(URL("")):
+			schema.Format = "uri"
+		}
+	case reflect.Bool:
+		schema.Type = "boolean"
+	}
+
+	return schema
+}
+
+// schemaValue returns the value of v, of the given field, which is validated against the schema passed to
+// HTTPDecoder.SetJSONSchema, and whether v has a value. It is built in the same way as the schemas generated by
+// JSONSchema, so properties are named after the struct fields, and values are formatted as they are rendered by the
+// HTMLEncoder, e.g. time.Time values use the layout of the field's format tag. Numbers are float64s, as if the value
+// had been decoded by encoding/json.
+func schemaValue(v reflect.Value, field StructField) (interface{}, bool) {
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			// the empty option of a nullable select is decoded as nil. Any other nil pointer was not submitted.
+			return nil, isNullableSelect(v.Type())
+		}
+
+		return schemaValue(v.Elem(), field)
+	}
+
+	if v.CanInterface() {
+		switch a := v.Interface().(type) {
+		case time.Time:
+			if loc, err := field.location(); err == nil && loc != nil {
+				a = a.In(loc)
+			}
+
+			return formatTimeValue(a, field.TimeFormat()), true
+		case big.Rat:
+			return a.RatString(), true
+		case Flags:
+			value := flagsValue(v)
+			flags := make([]interface{}, 0)
+
+			for _, flag := range a.FlagOptions() {
+				if flag.Value != 0 && value&flag.Value == flag.Value {
+					flags = append(flags, float64(flag.Value))
+				}
+			}
+
+			return flags, true
+		case CustomEncoder:
+			// the value of a CustomEncoder can't be described without knowing how it is rendered.
+			return nil, false
+		}
+
+		if isTextType(v.Type()) {
+			text, err := marshalText(v)
+
+			return text, err == nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+
+		for i, structField := range cachedStructFields(v.Type()) {
+			if !structField.IsExported() || structField.Computed() != "" {
+				continue
+			}
+
+			structField.timeLocation = field.timeLocation
+
+			if value, ok := schemaValue(v.Field(i), structField); ok {
+				properties[structField.Name] = value
+			}
+		}
+
+		return properties, true
+	case reflect.Slice, reflect.Array:
+		items := make([]interface{}, v.Len())
+
+		for i := range items {
+			items[i], _ = schemaValue(v.Index(i), StructField{timeLocation: field.timeLocation})
+		}
+
+		return items, true
+	case reflect.Map:
+		entries := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+
+		for iter.Next() {
+			if value, ok := schemaValue(iter.Value(), StructField{timeLocation: field.timeLocation}); ok {
+				entries[fmt.Sprint(iter.Key().Interface())] = value
+			}
+		}
+
+		return entries, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return v.String(), true
+	case reflect.Bool:
+		return v.Bool(), true
+	}
+
+	return nil, false
+}
+
+// numberConstraints adds the min, max and step tags of field to schema.
+func numberConstraints(schema *jsonSchema, field StructField) {
+	if field.HasMin() {
+		if min, err := strconv.ParseFloat(field.Min(), 64); err == nil {
+			schema.Minimum = &min
+		}
+	}
+
+	if field.HasMax() {
+		if max, err := strconv.ParseFloat(field.Max(), 64); err == nil {
+			schema.Maximum = &max
+		}
+	}
+
+	if field.HasStep() {
+		if step, err := strconv.ParseFloat(field.Step(), 64); err == nil && step > 0 {
+			schema.MultipleOf = &step
+		}
+	}
+}
+
+// optionValues returns the values of the options which are not disabled.
+func optionValues(options []Option) []interface{} {
+	var values []interface{}
+
+	for _, option := range options {
+		if option.Disabled {
+			continue
+		}
+
+		values = append(values, option.Value)
+	}
+
+	return values
+}
+
+// SchemaValidationMessages are the validation messages used for each JSON Schema keyword when a decoded value does
+// not satisfy the schema passed to HTTPDecoder.SetJSONSchema. Messages are formatted with the value of the keyword.
+var SchemaValidationMessages = map[string]string{
+	"type":       "This value must be of type %v",
+	"required":   "This field is required",
+	"enum":       "This value is not one of the allowed values",
+	"minimum":    "This value must be at least %v",
+	"maximum":    "This value must be at most %v",
+	"multipleOf": "This value must be a multiple of %v",
+	"minLength":  "This value must be at least %v characters",
+	"maxLength":  "This value must be at most %v characters",
+	"pattern":    "This value must match the pattern %v",
+}
+
+// schemaViolation is a value which does not satisfy a keyword of a JSON Schema.
+type schemaViolation struct {
+	// path is the form element name of the value, e.g. Address.HouseNumber or Tags[0].
+	path    string
+	value   interface{}
+	keyword string
+	arg     interface{}
+}
+
+// message returns the validation message of the violation. See SchemaValidationMessages.
+func (v schemaViolation) message() string {
+	message := SchemaValidationMessages[v.keyword]
+
+	if v.arg == nil {
+		return message
+	}
+
+	return fmt.Sprintf(message, v.arg)
+}
+
+// supportedSchemaKeywords are the keywords of a JSON Schema which can be passed to HTTPDecoder.SetJSONSchema.
+var supportedSchemaKeywords = map[string]bool{
+	"$schema":     true,
+	"type":        true,
+	"format":      true,
+	"title":       true,
+	"description": true,
+	"properties":  true,
+	"required":    true,
+	"items":       true,
+	"enum":        true,
+	"minimum":     true,
+	"maximum":     true,
+	"multipleOf":  true,
+	"minLength":   true,
+	"maxLength":   true,
+	"pattern":     true,
+	"readOnly":    true,
+}
+
+// compileSchema parses the JSON Schema b, and compiles its patterns. An error wrapping ErrInvalidJSONSchema is
+// returned if b can't be parsed, uses a keyword which is not in supportedSchemaKeywords, or has an invalid pattern.
+func compileSchema(b []byte) (*jsonSchema, error) {
+	if err := checkSchemaKeywords(b, "#"); err != nil {
+		return nil, err
+	}
+
+	var schema jsonSchema
+
+	if err := json.Unmarshal(b, &schema); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJSONSchema, err)
+	}
+
+	if err := compileSchemaPatterns(&schema, "#"); err != nil {
+		return nil, err
+	}
+
+	return &schema, nil
+}
+
+// checkSchemaKeywords returns an error if the schema b, or any of its subschemas, uses an unsupported keyword.
+// path is the JSON pointer of b, which is used in errors.
+func checkSchemaKeywords(b []byte, path string) error {
+	var keywords map[string]json.RawMessage
+
+	if err := json.Unmarshal(b, &keywords); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidJSONSchema, path, err)
+	}
+
+	for _, keyword := range sortedSchemaKeys(keywords) {
+		value := keywords[keyword]
+
+		if !supportedSchemaKeywords[keyword] {
+			return fmt.Errorf("%w: %s: unsupported keyword %q", ErrInvalidJSONSchema, path, keyword)
+		}
+
+		switch keyword {
+		case "items":
+			if err := checkSchemaKeywords(value, path+"/items"); err != nil {
+				return err
+			}
+		case "properties":
+			var properties map[string]json.RawMessage
+
+			if err := json.Unmarshal(value, &properties); err != nil {
+				return fmt.Errorf("%w: %s/properties: %v", ErrInvalidJSONSchema, path, err)
+			}
+
+			for _, name := range sortedSchemaKeys(properties) {
+				if err := checkSchemaKeywords(properties[name], path+"/properties/"+name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// sortedSchemaKeys returns the keys of m in order, so that the first of several errors is always reported.
+func sortedSchemaKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// compileSchemaPatterns compiles the pattern of schema and its subschemas. Patterns are anchored, in the same way
+// as the pattern attribute of an <input>.
+func compileSchemaPatterns(schema *jsonSchema, path string) error {
+	if schema == nil {
+		return nil
+	}
+
+	if schema.Pattern != "" {
+		re, err := regexp.Compile("^(?:" + schema.Pattern + ")$")
+
+		if err != nil {
+			return fmt.Errorf("%w: %s/pattern: %v", ErrInvalidJSONSchema, path, err)
+		}
+
+		schema.pattern = re
+	}
+
+	for name, property := range schema.Properties {
+		if err := compileSchemaPatterns(property, path+"/properties/"+name); err != nil {
+			return err
+		}
+	}
+
+	return compileSchemaPatterns(schema.Items, path+"/items")
+}
+
+// validateSchema validates the JSON value (as decoded by encoding/json) against schema, which must have been compiled
+// by compileSchema.
+func validateSchema(schema *jsonSchema, value interface{}, path string) []schemaViolation {
+	if schema == nil {
+		return nil
+	}
+
+	if schema.Type != "" && !schemaTypeMatches(schema.Type, value) {
+		return []schemaViolation{{path: path, value: value, keyword: "type", arg: schema.Type}}
+	}
+
+	var violations []schemaViolation
+
+	violation := func(keyword string, arg interface{}) {
+		violations = append(violations, schemaViolation{path: path, value: value, keyword: keyword, arg: arg})
+	}
+
+	if schema.Enum != nil && !containsJSONValue(schema.Enum, value) {
+		violation("enum", nil)
+	}
+
+	switch a := value.(type) {
+	case float64:
+		if schema.Minimum != nil && a < *schema.Minimum {
+			violation("minimum", *schema.Minimum)
+		}
+
+		if schema.Maximum != nil && a > *schema.Maximum {
+			violation("maximum", *schema.Maximum)
+		}
+
+		if schema.MultipleOf != nil && *schema.MultipleOf > 0 {
+			if quotient := a / *schema.MultipleOf; math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+				violation("multipleOf", *schema.MultipleOf)
+			}
+		}
+	case string:
+		length := len([]rune(a))
+
+		if schema.MinLength != nil && length < *schema.MinLength {
+			violation("minLength", *schema.MinLength)
+		}
+
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			violation("maxLength", *schema.MaxLength)
+		}
+
+		if schema.pattern != nil && !schema.pattern.MatchString(a) {
+			violation("pattern", schema.Pattern)
+		}
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if property, ok := a[name]; !ok || property == nil {
+				violations = append(violations, schemaViolation{path: schemaPath(path, name), keyword: "required"})
+			}
+		}
+
+		names := make([]string, 0, len(schema.Properties))
+
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			if property, ok := a[name]; ok {
+				violations = append(violations, validateSchema(schema.Properties[name], property, schemaPath(path, name))...)
+			}
+		}
+	case []interface{}:
+		for i, item := range a {
+			violations = append(violations, validateSchema(schema.Items, item, path+"["+strconv.Itoa(i)+"]")...)
+		}
+	}
+
+	return violations
+}
+
+// schemaPath returns the form element name of the property name within path.
+func schemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + fieldSeparator + name
+}
+
+// schemaTypeMatches determines if value is of the JSON Schema type t.
+func schemaTypeMatches(t string, value interface{}) bool {
+	switch a := value.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case float64:
+		return t == "number" || (t == "integer" && a == math.Trunc(a))
+	case string:
+		return t == "string"
+	case []interface{}:
+		return t == "array"
+	case map[string]interface{}:
+		return t == "object"
+	default:
+		return false
+	}
+}
+
+// containsJSONValue determines if values contains value.
+func containsJSONValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
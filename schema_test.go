@@ -0,0 +1,256 @@
+package formulate
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestJSONSchema(t *testing.T) {
+	type test struct {
+		Name     string  `name:"Full Name" help:"Your name" min:"2" max:"50" required:"true"`
+		Code     string  `pattern:"[A-Z]{3}"`
+		Age      int     `min:"18" max:"120"`
+		Weight   float64 `step:"0.5"`
+		Contact  Email
+		Birthday time.Time `format:"date"`
+		Meeting  time.Time
+		Alarm    time.Time `format:"time"`
+		Food     FoodSelect
+		Pet      *Pet
+		Agree    bool
+		Address  *Address
+		Tags     []string
+
+		unexportedField string
+	}
+
+	b, err := JSONSchema(&test{})
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	var schema jsonSchema
+
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assertEquals(t, schema.Type, "object")
+	assertEquals(t, len(schema.Properties), 13)
+	assertEquals(t, len(schema.Required), 1)
+	assertEquals(t, schema.Required[0], "Name")
+
+	name := schema.Properties["Name"]
+	assertEquals(t, name.Type, "string")
+	assertEquals(t, name.Title, "Full Name")
+	assertEquals(t, name.Description, "Your name")
+	assertEquals(t, *name.MinLength, 2)
+	assertEquals(t, *name.MaxLength, 50)
+
+	assertEquals(t, schema.Properties["Code"].Pattern, "[A-Z]{3}")
+	assertEquals(t, schema.Properties["Age"].Type, "integer")
+	assertEquals(t, *schema.Properties["Age"].Minimum, 18.0)
+	assertEquals(t, *schema.Properties["Age"].Maximum, 120.0)
+	assertEquals(t, *schema.Properties["Weight"].MultipleOf, 0.5)
+	assertEquals(t, schema.Properties["Contact"].Format, "email")
+	assertEquals(t, schema.Properties["Birthday"].Format, "date")
+	assertEquals(t, schema.Properties["Meeting"].Format, "")
+	assertEquals(t, schema.Properties["Meeting"].Pattern, `^[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}$`)
+	assertEquals(t, schema.Properties["Alarm"].Format, "")
+	assertEquals(t, schema.Properties["Alarm"].Pattern, `^[0-9]{2}:[0-9]{2}$`)
+	assertEquals(t, schema.Properties["Food"].Type, "array")
+	assertEquals(t, len(schema.Properties["Food"].Items.Enum), 4)
+	assertEquals(t, len(schema.Properties["Pet"].Enum), 4)
+	assertEquals(t, schema.Properties["Pet"].Enum[3], nil)
+	assertEquals(t, schema.Properties["Agree"].Type, "boolean")
+	assertEquals(t, schema.Properties["Address"].Type, "object")
+	assertEquals(t, schema.Properties["Address"].Properties["HouseName"].Description, "You can leave this blank.")
+	assertEquals(t, schema.Properties["Tags"].Items.Type, "string")
+
+	if _, err := JSONSchema("not a struct"); !errors.Is(err, ErrInvalidSchemaType) {
+		t.Errorf("Expected ErrInvalidSchemaType, got: %v", err)
+	}
+}
+
+func TestHTTPDecoder_SetJSONSchema(t *testing.T) {
+	type address struct {
+		HouseNumber int
+	}
+
+	type test struct {
+		Name    string
+		Age     int
+		Address address
+	}
+
+	schema := []byte(`{
+		"type": "object",
+		"properties": {
+			"Name": {"type": "string", "minLength": 2},
+			"Age": {"type": "integer", "minimum": 18},
+			"Address": {
+				"type": "object",
+				"properties": {
+					"HouseNumber": {"type": "integer", "minimum": 1}
+				}
+			}
+		}
+	}`)
+
+	t.Run("Schema violations are validation errors", func(t *testing.T) {
+		dec := NewDecoder(url.Values{
+			"Name":                {"Dave"},
+			"Age":                 {"16"},
+			"Address.HouseNumber": {"0"},
+		})
+
+		if err := dec.SetJSONSchema(schema); err != nil {
+			t.Error(err)
+			return
+		}
+
+		var x test
+
+		err := dec.Decode(&x)
+
+		var validationErrors ValidationErrors
+
+		if !errors.As(err, &validationErrors) {
+			t.Errorf("Expected ValidationErrors, got: %v", err)
+			return
+		}
+
+		assertEquals(t, 2, len(validationErrors))
+
+		for field, message := range map[string]string{
+			"Age":                 "This value must be at least 18",
+			"Address.HouseNumber": "This value must be at least 1",
+		} {
+			if len(validationErrors[field]) != 1 {
+				t.Errorf("Expected one validation error for %s, got: %v", field, validationErrors)
+				continue
+			}
+
+			assertEquals(t, message, validationErrors[field][0].Error)
+		}
+
+		assertEquals(t, float64(16), validationErrors["Age"][0].Value)
+	})
+
+	t.Run("Valid values pass", func(t *testing.T) {
+		dec := NewDecoder(url.Values{
+			"Name":                {"Dave"},
+			"Age":                 {"30"},
+			"Address.HouseNumber": {"12"},
+		})
+
+		if err := dec.SetJSONSchema(schema); err != nil {
+			t.Error(err)
+			return
+		}
+
+		var x test
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, 30, x.Age)
+	})
+
+	t.Run("Invalid schemas", func(t *testing.T) {
+		for _, invalid := range []string{
+			`{`,
+			`{"type": "object", "properties": {"Name": {"type": "string", "format": "email", "maxLength": 10}}, "additionalProperties": false}`,
+			`{"type": "object", "properties": {"Tags": {"type": "array", "items": {"$ref": "#/definitions/tag"}}}}`,
+			`{"type": "object", "properties": {"Name": {"type": "string", "pattern": "[a-z"}}}`,
+		} {
+			dec := NewDecoder(url.Values{})
+
+			if err := dec.SetJSONSchema([]byte(invalid)); !errors.Is(err, ErrInvalidJSONSchema) {
+				t.Errorf("Expected ErrInvalidJSONSchema for %s, got: %v", invalid, err)
+			}
+		}
+	})
+
+	t.Run("Generated schemas can be used", func(t *testing.T) {
+		generated, err := JSONSchema(test{})
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if err := NewDecoder(url.Values{}).SetJSONSchema(generated); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Round trip generated schema", func(t *testing.T) {
+		type roundTrip struct {
+			Name     string    `min:"2"`
+			Age      int       `min:"1" json:"age"`
+			Meeting  time.Time `tz:"Europe/London"`
+			Birthday time.Time `format:"date"`
+			Nickname *string
+		}
+
+		generated, err := JSONSchema(roundTrip{})
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		dec := NewDecoder(url.Values{
+			"Name":     {"Dave"},
+			"Age":      {"30"},
+			"Meeting":  {"2024-01-02T03:04"},
+			"Birthday": {"1990-05-06"},
+		})
+
+		if err := dec.SetJSONSchema(generated); err != nil {
+			t.Error(err)
+			return
+		}
+
+		var x roundTrip
+
+		if err := dec.Decode(&x); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assertEquals(t, 30, x.Age)
+
+		dec = NewDecoder(url.Values{
+			"Name":     {"D"},
+			"Age":      {"0"},
+			"Meeting":  {"2024-01-02T03:04"},
+			"Birthday": {"1990-05-06"},
+		})
+
+		if err := dec.SetJSONSchema(generated); err != nil {
+			t.Error(err)
+			return
+		}
+
+		var validationErrors ValidationErrors
+
+		if err := dec.Decode(&x); !errors.As(err, &validationErrors) {
+			t.Errorf("Expected ValidationErrors, got: %v", err)
+			return
+		}
+
+		assertEquals(t, 2, len(validationErrors))
+		assertEquals(t, 1, len(validationErrors["Name"]))
+		assertEquals(t, 1, len(validationErrors["Age"]))
+	})
+}
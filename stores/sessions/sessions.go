@@ -28,6 +28,8 @@ type Store struct {
 	sessionName   string
 }
 
+var _ formulate.ValidationStore = &Store{}
+
 // NewStore creates a session store for saving validation. The sessionName provided must be unique to each form instance.
 func NewStore(r *http.Request, w http.ResponseWriter, store sessions.Store, sessionName string) *Store {
 	return &Store{
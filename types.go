@@ -1,8 +1,12 @@
 package formulate
 
 import (
+	"encoding"
+	"fmt"
 	"net/url"
 	"reflect"
+	"strconv"
+	"time"
 
 	"golang.org/x/net/html"
 )
@@ -15,6 +19,8 @@ type CustomEncoder interface {
 	// BuildFormElement is passed the key of the form element as computed by formulate,
 	// the parent node of the element, the field of the struct
 	// that is currently being rendered, and the form's decorator.
+	// The field's Required, ValidationErrors, LabelID, HelpTextID and ValidationTextID can be used to add ARIA
+	// attributes to the element.
 	// Note that the built element must be appended to the parent or it will not be shown in the form!
 	// Errors returned from BuildFormElement propagate back through to the formulate.Encoder.Encode call.
 	BuildFormElement(key string, parent *html.Node, field StructField, decorator Decorator) error
@@ -27,14 +33,66 @@ type CustomDecoder interface {
 	// DecodeFormValue is passed the whole form values, the name of the element that it is decoding,
 	// and the values for that specific element. It must return a reflect.Value of equal type to the
 	// type which is implementing the CustomDecoder interface. If err != nil, the error will propagate
-	// back through to the Decode() call.
+	// back through to the Decode() call, wrapped in a DecodeError which contains the submitted value, unless
+	// HTTPDecoder.SetCustomDecoderErrorsAsValidation is enabled.
 	//
 	// By default, primitive types supported by formulate will remove the values from the form as the form is decoded.
 	// CustomDecoders may replicate this behaviour if needed, but formulate will not do it automatically.
 	// See PopFormValue and FormElementName for more information.
+	//
+	// values is empty when the element is absent from the form, e.g. an unchecked checkbox or a multi-select
+	// with nothing selected, so implementations must check its length before indexing it. Return an invalid
+	// reflect.Value to leave the field unchanged.
 	DecodeFormValue(form url.Values, name string, values []string) (reflect.Value, error)
 }
 
+// OptionalValue is a marker interface for optional value types, such as a generic Optional[T]. IsOptional must
+// return true. As Go interfaces can't describe generic methods, a type implementing OptionalValue must also have
+// the following methods, which formulate calls using reflection:
+//
+//	GetValue() (T, bool) // returns the value, and whether it is present.
+//	SetValue(T)          // sets the value and marks it as present. This may have a pointer receiver.
+//
+// T may be any type which formulate can encode and decode. When encoding, the value is rendered as if the field
+// were of type T. Absent values are rendered with an empty value. When decoding, SetValue is called with the decoded
+// value. An empty form value resets the field to its zero value, which should be absent, and fields which are not in
+// the form are left unchanged. If the methods are missing or have the wrong signatures, ErrInvalidOptional is returned.
+type OptionalValue interface {
+	IsOptional() bool
+}
+
+// optionalGetter returns the GetValue method of the OptionalValue v.
+func optionalGetter(v reflect.Value) (reflect.Value, error) {
+	getValue := v.MethodByName("GetValue")
+
+	if !getValue.IsValid() && v.CanAddr() {
+		getValue = v.Addr().MethodByName("GetValue")
+	}
+
+	if !getValue.IsValid() || getValue.Type().NumIn() != 0 || getValue.Type().NumOut() != 2 || getValue.Type().Out(1).Kind() != reflect.Bool {
+		return reflect.Value{}, fmt.Errorf("%w: %s must have a GetValue() (T, bool) method", ErrInvalidOptional, v.Type())
+	}
+
+	return getValue, nil
+}
+
+// optionalSetter returns the SetValue method of the OptionalValue v.
+func optionalSetter(v reflect.Value) (reflect.Value, error) {
+	var setValue reflect.Value
+
+	if v.CanAddr() {
+		setValue = v.Addr().MethodByName("SetValue")
+	} else {
+		setValue = v.MethodByName("SetValue")
+	}
+
+	if !setValue.IsValid() || setValue.Type().NumIn() != 1 || setValue.Type().NumOut() != 0 {
+		return reflect.Value{}, fmt.Errorf("%w: %s must have a SetValue(T) method", ErrInvalidOptional, v.Type())
+	}
+
+	return setValue, nil
+}
+
 type (
 	// Password represents an <input type="password">
 	Password string
@@ -55,11 +113,64 @@ type Select interface {
 	SelectOptions() []Option
 }
 
+var selectType = reflect.TypeOf((*Select)(nil)).Elem()
+
+// isNullableSelect determines if t is a pointer to a type which implements Select, e.g. *Status. Nullable
+// Selects are rendered with an empty option, which is decoded as a nil pointer.
+func isNullableSelect(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && t.Elem().Implements(selectType)
+}
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// isTextType determines if t is rendered and decoded as text, using its encoding.TextMarshaler and
+// encoding.TextUnmarshaler implementations, e.g. net.IP. Both interfaces must be implemented so that values
+// round trip. Pointer types are excluded; they are dereferenced as usual.
+func isTextType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface {
+		return false
+	}
+
+	pt := reflect.PtrTo(t)
+
+	return (t.Implements(textMarshalerType) || pt.Implements(textMarshalerType)) && pt.Implements(textUnmarshalerType)
+}
+
+// marshalText returns the text form of v, whose type must satisfy isTextType.
+func marshalText(v reflect.Value) (string, error) {
+	if !v.Type().Implements(textMarshalerType) {
+		// MarshalText has a pointer receiver, so take the address of v (or a copy of v, if it is not addressable).
+		if !v.CanAddr() {
+			p := reflect.New(v.Type())
+			p.Elem().Set(v)
+			v = p.Elem()
+		}
+
+		v = v.Addr()
+	}
+
+	text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(text), nil
+}
+
 // Option represents an option in Select inputs and Radio inputs.
 type Option struct {
 	Value interface{}
 	Label string
 	Group *string
+	// GroupDisabled disables the whole <optgroup> of the option. An optgroup is disabled if any of its options
+	// set GroupDisabled.
+	GroupDisabled bool
+	// Title is rendered as the title (tooltip) of the option.
+	Title string
 
 	Disabled bool
 	Checked  *Condition
@@ -80,6 +191,63 @@ func NewCondition(b bool) *Condition {
 	return &c
 }
 
+// Enum is a lighter alternative to Select for types with a fixed set of values, such as string enums. Types which
+// implement Enum (but not Select) are rendered as a single select, with an option for each value. The option
+// matching the value of the field is selected.
+type Enum interface {
+	// Values are the available values, which are also used as the labels of the options.
+	Values() []string
+}
+
+// EnumOptions is the same as Enum, but allows the label, group and attributes of each option to be set.
+type EnumOptions interface {
+	// Values are the available options.
+	Values() []Option
+}
+
+// enumSelect is the Select synthesized for an Enum or EnumOptions.
+type enumSelect struct {
+	value   interface{}
+	options []Option
+}
+
+// newEnumSelect returns the Select for e, which must implement Enum or EnumOptions.
+func newEnumSelect(e interface{}) Select {
+	s := enumSelect{value: e}
+
+	switch a := e.(type) {
+	case Enum:
+		for _, value := range a.Values() {
+			s.options = append(s.options, Option{Value: value, Label: value})
+		}
+	case EnumOptions:
+		s.options = a.Values()
+	}
+
+	return s
+}
+
+// SelectMultiple implements the Select interface. Enums are always single selects.
+func (s enumSelect) SelectMultiple() bool {
+	return false
+}
+
+// SelectOptions implements the Select interface. Options without a Checked condition are checked if their value
+// matches the value of the Enum.
+func (s enumSelect) SelectOptions() []Option {
+	options := make([]Option, len(s.options))
+
+	for i, opt := range s.options {
+		if opt.Checked == nil {
+			opt.Checked = NewCondition(toString(opt.Value) == toString(s.value))
+		}
+
+		options[i] = opt
+	}
+
+	return options
+}
+
 // RadioList represents a list of <input type="radio">. Radio lists must implement their own decoder.
 type RadioList interface {
 	CustomDecoder
@@ -87,16 +255,99 @@ type RadioList interface {
 	RadioOptions() []Option
 }
 
+// CheckboxGroup represents a list of <input type="checkbox"> which share a name. It must be a slice of a string,
+// bool or numeric type, e.g. []string or []int. The values of the checked options are decoded into the slice.
+// An empty hidden value is rendered alongside the checkboxes, so that if no options are checked, the slice is
+// decoded as empty. The slice is only left unchanged if the group is absent from the form.
+type CheckboxGroup interface {
+	CheckboxOptions() []Option
+}
+
+// Datalist provides suggested values for a text input, which are rendered in a <datalist>.
+// Unlike a Select, any value can be entered in the input. String types which implement Datalist
+// are rendered with a <datalist> automatically.
+type Datalist interface {
+	DatalistOptions() []Option
+}
+
+// SuggestText is a text input with a list of suggested values, which are set by NewSuggestText. The submitted
+// value is decoded into Text as a plain string, and the suggestions are kept.
+//
+// SuggestText is a struct rather than a string type because its suggestions belong to each value. A string type can
+// only provide suggestions from its DatalistOptions method, which has nowhere to store them. If the suggestions are
+// the same for every value, declare a string type which implements Datalist instead, e.g.
+//
+//	type Colour string
+//
+//	func (c Colour) DatalistOptions() []Option {
+//		return []Option{{Value: "red"}, {Value: "green"}, {Value: "blue"}}
+//	}
+type SuggestText struct {
+	Text string
+
+	suggestions []string
+}
+
+// NewSuggestText creates a SuggestText with the given text and suggestions.
+func NewSuggestText(text string, suggestions ...string) SuggestText {
+	return SuggestText{
+		Text:        text,
+		suggestions: suggestions,
+	}
+}
+
+// String implements the fmt.Stringer interface.
+func (s SuggestText) String() string {
+	return s.Text
+}
+
+// DatalistOptions implements the Datalist interface.
+func (s SuggestText) DatalistOptions() []Option {
+	options := make([]Option, len(s.suggestions))
+
+	for i, suggestion := range s.suggestions {
+		options[i] = Option{Value: suggestion, Label: suggestion}
+	}
+
+	return options
+}
+
+// BuildFormElement implements the CustomEncoder interface.
+func (s SuggestText) BuildFormElement(key string, parent *html.Node, field StructField, decorator Decorator) error {
+	n := BuildStringField(reflect.ValueOf(s.Text), key, field)
+	parent.AppendChild(n)
+	decorator.TextField(n, field)
+
+	appendDatalist(parent, n, s, field.ElementID(key))
+
+	return nil
+}
+
+// DecodeFormValue implements the CustomDecoder interface.
+func (s SuggestText) DecodeFormValue(form url.Values, name string, _ []string) (reflect.Value, error) {
+	val, ok := PopFormValue(form, FormElementName(name))
+
+	if !ok {
+		return reflect.Value{}, nil
+	}
+
+	return reflect.ValueOf(SuggestText{Text: val, suggestions: s.suggestions}), nil
+}
+
 // BoolNumber represents an int (0 or 1) which should actually be rendered as a checkbox.
 // It is provided here as a convenience, as many structures use 0 or 1 to represent booleans values.
 type BoolNumber int
 
 // DecodeFormValue implements the CustomDecoder interface.
+// If the checkbox is submitted alongside a hidden fallback value, it is decoded as 1 if any of its values are true,
+// e.g. "on", "1", "true" or "yes". Values which are not booleans are treated as false.
 func (bn BoolNumber) DecodeFormValue(form url.Values, name string, _ []string) (reflect.Value, error) {
-	val, _ := PopFormValue(form, FormElementName(name))
+	formValues := popAllFormValues(form, FormElementName(name))
 
-	if val == "on" || val == "1" {
-		return reflect.ValueOf(BoolNumber(1)), nil
+	for _, formValue := range formValues {
+		if b, _ := parseBool(formValue); b {
+			return reflect.ValueOf(BoolNumber(1)), nil
+		}
 	}
 
 	return reflect.ValueOf(BoolNumber(0)), nil
@@ -107,6 +358,346 @@ func (bn BoolNumber) Bool() bool {
 	return bn == 1
 }
 
+// Flag is a single bit flag of a Flags type.
+type Flag struct {
+	Value uint64
+	Label string
+}
+
+// Flags is implemented by integer bit flag types, e.g. type Permission uint8, to list their flags. Fields of these
+// types are rendered as a <select multiple>, with an option for each flag, and the options of the flags which are
+// set are selected. When decoding, the values of the selected options are combined with a bitwise OR.
+//
+// FlagOptions is called on the zero value of the type when decoding, so it must not depend on the value. An empty
+// hidden value is rendered alongside the <select>, so that a field with no selected options is decoded as 0.
+type Flags interface {
+	FlagOptions() []Flag
+}
+
+// flagsSelect is the Select used to render a Flags value.
+type flagsSelect struct {
+	value uint64
+	flags []Flag
+}
+
+// SelectMultiple implements the Select interface.
+func (s flagsSelect) SelectMultiple() bool {
+	return true
+}
+
+// SelectOptions implements the Select interface. The options of the flags whose bits are all set are selected.
+func (s flagsSelect) SelectOptions() []Option {
+	options := make([]Option, len(s.flags))
+
+	for i, flag := range s.flags {
+		options[i] = Option{
+			Value:   flag.Value,
+			Label:   flag.Label,
+			Checked: NewCondition(flag.Value != 0 && s.value&flag.Value == flag.Value),
+		}
+	}
+
+	return options
+}
+
+// flagsSentinel is the value of the hidden input which is submitted with every Flags field.
+const flagsSentinel = ""
+
+// flagsValue returns the bits of v, which must be of an integer kind.
+func flagsValue(v reflect.Value) uint64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint64(v.Int())
+	default:
+		return v.Uint()
+	}
+}
+
+// isFlag determines if value is one of the flags.
+func isFlag(flags []Flag, value uint64) bool {
+	for _, flag := range flags {
+		if flag.Value == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Month is a time.Time which is rendered as an <input type="month">, with a value such as 2006-01.
+// It is decoded as midnight UTC on the first day of the month.
+type Month struct {
+	time.Time
+}
+
+// BuildFormElement implements the CustomEncoder interface.
+func (m Month) BuildFormElement(key string, parent *html.Node, field StructField, decorator Decorator) error {
+	n := buildTimeInput(m.Time, key, field, "month")
+	appendFormElement(parent, n, field)
+	decorator.TimeField(n, field)
+
+	return nil
+}
+
+// DecodeFormValue implements the CustomDecoder interface.
+func (m Month) DecodeFormValue(form url.Values, name string, _ []string) (reflect.Value, error) {
+	t, ok, err := decodeTimeValue(form, name, "month")
+
+	if err != nil || !ok {
+		return reflect.Value{}, err
+	}
+
+	return reflect.ValueOf(Month{t}), nil
+}
+
+// Week is a time.Time which is rendered as an <input type="week">, with an ISO 8601 week value such as 2006-W01.
+// It is decoded as midnight UTC on the Monday at the start of the week.
+type Week struct {
+	time.Time
+}
+
+// BuildFormElement implements the CustomEncoder interface.
+func (w Week) BuildFormElement(key string, parent *html.Node, field StructField, decorator Decorator) error {
+	n := buildTimeInput(w.Time, key, field, "week")
+	appendFormElement(parent, n, field)
+	decorator.TimeField(n, field)
+
+	return nil
+}
+
+// DecodeFormValue implements the CustomDecoder interface.
+func (w Week) DecodeFormValue(form url.Values, name string, _ []string) (reflect.Value, error) {
+	t, ok, err := decodeTimeValue(form, name, "week")
+
+	if err != nil || !ok {
+		return reflect.Value{}, err
+	}
+
+	return reflect.ValueOf(Week{t}), nil
+}
+
+// decodeTimeValue pops the value of the time input with the given name and format from the form, and parses it in UTC.
+// An empty value is decoded as the zero time.
+func decodeTimeValue(form url.Values, name, format string) (t time.Time, ok bool, err error) {
+	val, ok := PopFormValue(form, FormElementName(name))
+
+	if !ok || val == "" {
+		return time.Time{}, ok, nil
+	}
+
+	t, err = parseTimeValue(format, val, time.UTC)
+
+	if err != nil {
+		return time.Time{}, true, newDecodeError(name, val, err)
+	}
+
+	return t, true, nil
+}
+
+// ComputedValue is the result of a computed field (see the computed struct tag). It is rendered as
+// an <output> element, which is display-only and not submitted with the form.
+type ComputedValue string
+
+// BuildFormElement implements the CustomEncoder interface.
+func (c ComputedValue) BuildFormElement(key string, parent *html.Node, field StructField, decorator Decorator) error {
+	n := &html.Node{
+		Type: html.ElementNode,
+		Data: "output",
+		Attr: []html.Attribute{
+			{
+				Key: "id",
+				Val: field.ElementID(key),
+			},
+		},
+	}
+
+	n.AppendChild(&html.Node{
+		Type: html.TextNode,
+		Data: string(c),
+	})
+
+	parent.AppendChild(n)
+
+	return nil
+}
+
+// Meter is a value within a known range, which is rendered as a display-only <meter> element. The range is set with
+// the min and max tags (e.g. min:"0" max:"100"). Meters have no name, so they are not submitted with the form.
+type Meter float64
+
+// BuildFormElement implements the CustomEncoder interface.
+func (m Meter) BuildFormElement(key string, parent *html.Node, field StructField, decorator Decorator) error {
+	n := buildMeterElement("meter", float64(m), key, field)
+
+	if field.HasMin() {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "min",
+			Val: field.Min(),
+		})
+	}
+
+	if field.HasMax() {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "max",
+			Val: field.Max(),
+		})
+	}
+
+	parent.AppendChild(n)
+
+	if decorator, ok := decorator.(MeterDecorator); ok {
+		decorator.Meter(n, field)
+	}
+
+	return nil
+}
+
+// Progress is the completion of a task, which is rendered as a display-only <progress> element. The value is
+// between 0 and the max tag (e.g. max:"100"), or between 0 and 1 if there is no max tag. Progress elements have no
+// name, so they are not submitted with the form.
+type Progress float64
+
+// BuildFormElement implements the CustomEncoder interface.
+func (p Progress) BuildFormElement(key string, parent *html.Node, field StructField, decorator Decorator) error {
+	n := buildMeterElement("progress", float64(p), key, field)
+
+	if field.HasMax() {
+		n.Attr = append(n.Attr, html.Attribute{
+			Key: "max",
+			Val: field.Max(),
+		})
+	}
+
+	parent.AppendChild(n)
+
+	if decorator, ok := decorator.(MeterDecorator); ok {
+		decorator.Progress(n, field)
+	}
+
+	return nil
+}
+
+// buildMeterElement builds a <meter> or <progress> element with the given value, which is also used as its text
+// for browsers which don't support the element.
+func buildMeterElement(elem string, value float64, key string, field StructField) *html.Node {
+	formattedValue := strconv.FormatFloat(value, 'f', -1, 64)
+
+	n := &html.Node{
+		Type: html.ElementNode,
+		Data: elem,
+		Attr: []html.Attribute{
+			{
+				Key: "id",
+				Val: field.ElementID(key),
+			},
+			{
+				Key: "value",
+				Val: formattedValue,
+			},
+		},
+	}
+
+	n.AppendChild(&html.Node{
+		Type: html.TextNode,
+		Data: formattedValue,
+	})
+
+	return n
+}
+
+// DefaultRatingMax is the maximum value of a Rating field without a max tag.
+const DefaultRatingMax = 5
+
+// RatingOptionLabel is the label of each star of a Rating, formatted with the value of the star and the maximum.
+var RatingOptionLabel = "%d of %d"
+
+// Rating is a rating from 1 to the max tag (e.g. max:"10"), or from 1 to DefaultRatingMax if there is no max tag,
+// which is rendered as a radio input for each star, with the current rating checked. The radios are wrapped in a
+// <div data-rating>, and each radio has a data-rating-value attribute, which can be styled as stars by a Decorator
+// which implements RatingDecorator. A Rating of 0 is unrated, so no star is checked.
+type Rating int
+
+// BuildFormElement implements the CustomEncoder interface.
+func (r Rating) BuildFormElement(key string, parent *html.Node, field StructField, decorator Decorator) error {
+	max := DefaultRatingMax
+
+	if field.HasMax() {
+		m, err := strconv.Atoi(field.Max())
+
+		if err != nil {
+			return err
+		}
+
+		max = m
+	}
+
+	n := BuildRadioButtons(ratingList{Rating: r, max: max}, key, field, decorator)
+	n.Attr = append(n.Attr, html.Attribute{
+		Key: "data-rating",
+		Val: strconv.Itoa(max),
+	})
+
+	parent.AppendChild(n)
+
+	if decorator, ok := decorator.(RatingDecorator); ok {
+		decorator.Rating(n, field)
+	}
+
+	return nil
+}
+
+// DecodeFormValue implements the CustomDecoder interface. An empty value is decoded as 0 (unrated), and an absent
+// value leaves the Rating unchanged.
+func (r Rating) DecodeFormValue(form url.Values, name string, _ []string) (reflect.Value, error) {
+	val, ok := PopFormValue(form, FormElementName(name))
+
+	if !ok {
+		return reflect.Value{}, nil
+	}
+
+	if val == "" {
+		return reflect.ValueOf(Rating(0)), nil
+	}
+
+	rating, err := strconv.Atoi(val)
+
+	if err != nil || rating < 0 {
+		return reflect.Value{}, newDecodeError(name, val, ErrInvalidRating)
+	}
+
+	return reflect.ValueOf(Rating(rating)), nil
+}
+
+// ratingList is the RadioList of the stars of a Rating.
+type ratingList struct {
+	Rating
+
+	max int
+}
+
+// RadioOptions implements the RadioList interface.
+func (r ratingList) RadioOptions() []Option {
+	options := make([]Option, r.max)
+
+	for i := range options {
+		value := i + 1
+
+		options[i] = Option{
+			Value:   value,
+			Label:   fmt.Sprintf(RatingOptionLabel, value, r.max),
+			Checked: NewCondition(value == int(r.Rating)),
+			Attr: []html.Attribute{
+				{
+					Key: "data-rating-value",
+					Val: strconv.Itoa(value),
+				},
+			},
+		}
+	}
+
+	return options
+}
+
 // Raw is byte data which should be rendered as a string inside a textarea.
 type Raw []byte
 
@@ -122,7 +713,7 @@ func (r Raw) BuildFormElement(key string, parent *html.Node, field StructField,
 			},
 			{
 				Key: "id",
-				Val: key,
+				Val: field.ElementID(key),
 			},
 		},
 	}
@@ -1,9 +1,12 @@
 package formulate
 
 import (
+	"context"
 	"errors"
 	"net/url"
 	"reflect"
+
+	"golang.org/x/net/html"
 )
 
 // Validator is an interface that allows individual form fields to be validated as part of the Decode phase of a formulate
@@ -25,9 +28,96 @@ type FormAwareValidator interface {
 	SetForm(form url.Values)
 }
 
+// ContextValidator is a Validator which is passed the context given to HTTPDecoder.DecodeContext. This can be
+// used for validation which depends on slow operations, such as database lookups, which should be cancelled
+// along with the request. If a Validator implements ContextValidator, ValidateContext is called instead of Validate.
+type ContextValidator interface {
+	Validator
+
+	ValidateContext(ctx context.Context, value interface{}) (ok bool, message string)
+}
+
+// StructAwareValidator is a Validator that is aware of the struct that is being decoded. This can be used for
+// validation that compares typed values of other fields, e.g. checking that a password confirmation matches.
+//
+// SetStruct is called with the struct value passed to HTTPDecoder.Decode before any fields are decoded.
+// Fields are decoded in the order they are declared, so when a field is validated, only the fields
+// declared before it in the struct have been decoded. Fields that are compared against must therefore
+// be declared before the field which uses the StructAwareValidator.
+type StructAwareValidator interface {
+	Validator
+
+	SetStruct(v reflect.Value)
+}
+
+// HTMLAttributeValidator is a Validator which can describe its rules as HTML attributes, e.g. a minimum length
+// validator may return a minlength attribute. Validators which are registered with HTMLEncoder.AddValidators
+// and implement HTMLAttributeValidator add their attributes to the form elements of fields which use them. This
+// allows browsers to validate fields before the form is submitted. The Validator is still run when decoding.
+type HTMLAttributeValidator interface {
+	Validator
+
+	HTMLAttributes() []html.Attribute
+}
+
+// RequiredIfValidator is a FormAwareValidator which requires a non-zero value when the form element named Field
+// was submitted with the given Value. The HTTPDecoder runs a RequiredIfValidator for each field with a requiredwhen
+// struct tag, so it does not need to be registered for those fields.
+type RequiredIfValidator struct {
+	// Field is the form element name of the field which the condition depends on, e.g. "ConfirmedEmail".
+	Field string
+	// Value is the value of Field which makes the validated field required.
+	Value string
+
+	form url.Values
+}
+
+// RequiredIfValidationMessage is the validation message used when a conditionally required field is empty.
+var RequiredIfValidationMessage = "This field is required"
+
+// SetForm implements the FormAwareValidator interface.
+func (r *RequiredIfValidator) SetForm(form url.Values) {
+	r.form = form
+}
+
+// Validate implements the Validator interface.
+func (r *RequiredIfValidator) Validate(value interface{}) (ok bool, message string) {
+	if !containsString(r.form[r.Field], r.Value) {
+		return true, ""
+	}
+
+	if v := reflect.ValueOf(value); v.IsValid() && !v.IsZero() {
+		return true, ""
+	}
+
+	return false, RequiredIfValidationMessage
+}
+
+// TagName implements the Validator interface.
+func (r *RequiredIfValidator) TagName() string {
+	return "requiredIf"
+}
+
 // ErrFormFailedValidation is returned if any form fields did not pass validation.
+// The error returned from HTTPDecoder.Decode is a ValidationErrors, which can be compared
+// to ErrFormFailedValidation using errors.Is.
 var ErrFormFailedValidation = errors.New("formulate: form failed validation")
 
+// ValidationErrors is returned by HTTPDecoder.Decode if any form fields did not pass validation.
+// It maps the form element name of each field to the ValidationErrors for that field, so that
+// validation failures can be inspected without a ValidationStore. Use errors.As to retrieve it.
+type ValidationErrors map[string][]ValidationError
+
+// Error implements the error interface.
+func (v ValidationErrors) Error() string {
+	return ErrFormFailedValidation.Error()
+}
+
+// Is allows ValidationErrors to be matched against ErrFormFailedValidation using errors.Is.
+func (v ValidationErrors) Is(target error) bool {
+	return target == ErrFormFailedValidation
+}
+
 // ValidatorKey is used to match the Validator's TagName against that on a StructField.
 type ValidatorKey string
 
@@ -40,7 +130,8 @@ type ValidationError struct {
 	Value interface{}
 }
 
-// ValidationStore is a data store for the validation errors
+// ValidationStore is a data store for the validation errors. It is used by both the HTMLEncoder and HTTPDecoder
+// (see SetValidationStore), so implementations must provide all of the methods below.
 type ValidationStore interface {
 	// GetValidationErrors returns the errors for a given field.
 	GetValidationErrors(field string) ([]ValidationError, error)
@@ -58,12 +149,16 @@ type ValidationStore interface {
 	GetFormValue(out interface{}) error
 }
 
+// MemoryValidationStore is a ValidationStore which keeps validation errors and form values in memory.
 type MemoryValidationStore struct {
 	validationErrors map[string][]ValidationError
 
 	val interface{}
 }
 
+var _ ValidationStore = &MemoryValidationStore{}
+
+// NewMemoryValidationStore creates an empty MemoryValidationStore.
 func NewMemoryValidationStore() *MemoryValidationStore {
 	return &MemoryValidationStore{
 		validationErrors: make(map[string][]ValidationError),